@@ -1,28 +1,50 @@
 package configurablecommand
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/li-go/gobot/cmdargparser"
 	"github.com/li-go/gobot/gobot"
 )
 
-var (
-	errMsgFmt = "```\nerror:\n  %s\n```\n:thinking_face:"
+const (
+	askTimeout = time.Minute
+
+	// OnShutdownStop stops the running task on shutdown and restarts it next time, it's the default
+	OnShutdownStop = "stop"
+	// OnShutdownWait lets the running task finish on shutdown
+	OnShutdownWait = "wait"
+)
 
-	ErrNoPermission = errors.New("no permission")
+var (
+	ErrNoPermission = gobot.NewError("error.no_permission")
+	ErrNoAnswer     = gobot.NewError("command.no_answer")
+	ErrNotMatched   = errors.New("not a command line of the command")
 )
 
 type Command struct {
-	Name         string
-	Command      string
-	ParamNames   []string `yaml:"params"`
+	Name       string
+	Command    string
+	ParamNames []string `yaml:"params"`
+	// RequiredParamNames are asked for when missing, they should be listed in ParamNames as well
+	RequiredParamNames []string `yaml:"required"`
+	// Confirm is a yes/no question the user has to answer before the command is queued
+	Confirm      string   `yaml:"confirm"`
 	LogFilename  string   `yaml:"log"`
 	ErrChannelID string   `yaml:"error_channel"`
 	ChannelNames []string `yaml:"channels"`
 	UserNames    []string `yaml:"users"`
+	// Thread posts progress, errors and completion into the thread of the triggering message
+	Thread bool `yaml:"thread"`
+	// Progress keeps one message updated with the elapsed time and the last output while running
+	Progress bool `yaml:"progress"`
+	// OnShutdown is OnShutdownStop or OnShutdownWait
+	OnShutdown string `yaml:"on_shutdown"`
 }
 
 func (c Command) Handler() gobot.Handler {
@@ -35,24 +57,111 @@ func (c Command) Handler() gobot.Handler {
 			return m
 		},
 		Handle: func(bot gobot.Bot, msg gobot.Message) error {
-			return addTask(bot, msg, c)
+			// the task queued by the text before the edit is replaced by the one of the new text
+			if msg.Edited {
+				CancelTasks(msg.Workspace, msg.Ref())
+			}
+			msg, err := c.askMissingParams(bot, msg)
+			if err != nil {
+				return err
+			}
+			if len(c.Confirm) > 0 {
+				ok, err := c.confirm(bot, msg)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					c.reply(bot, msg, msg.T("task.cancelled", msg.Text))
+					return nil
+				}
+			}
+			if _, err := addTask(bot, msg, c); err != nil {
+				return err
+			}
+			react(bot, msg, reactionAccepted)
+			return nil
 		},
 	}
 }
 
+// StartTask queues a task of the command for msg, whose text is the command line, e.g. `dist-beta --branch foo`,
+// and returns its ID. Unlike the handler of the command, missing params are not asked for and nothing is confirmed.
+func (c Command) StartTask(bot gobot.Bot, msg gobot.Message) (int, error) {
+	if ok, _ := c.match(msg.Text); !ok {
+		return 0, ErrNotMatched
+	}
+	id, err := addTask(bot, msg, c)
+	if err != nil {
+		return 0, err
+	}
+	react(bot, msg, reactionAccepted)
+	return id, nil
+}
+
 func (c Command) help() string {
 	ss := []string{c.Name}
 	for _, p := range c.ParamNames {
+		if c.isRequiredParamName(p) {
+			ss = append(ss, fmt.Sprintf("--%s=<%s>", p, p))
+			continue
+		}
 		ss = append(ss, fmt.Sprintf("[--%s=<%s>]", p, p))
 	}
 	return strings.Join(ss, " ")
 }
 
-func (c Command) newExecutor(bot gobot.Bot, msg gobot.Message) (*Executor, error) {
+// askMissingParams asks the user for the required params missing in msg and appends the answers to its text.
+func (c Command) askMissingParams(bot gobot.Bot, msg gobot.Message) (gobot.Message, error) {
+	_, paramString := c.match(msg.Text)
+	params, err := cmdargparser.Parse(paramString)
+	if err != nil {
+		// reported when the task is executed
+		return msg, nil
+	}
+	for _, name := range c.RequiredParamNames {
+		var found bool
+		for _, p := range params {
+			if p.Name == name {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), askTimeout)
+		answer, err := bot.Ask(ctx, msg, msg.T("command.input", name))
+		cancel()
+		if err == context.DeadlineExceeded {
+			return msg, ErrNoAnswer
+		}
+		if err != nil {
+			return msg, err
+		}
+		msg.Text += " --" + name + " " + quoteParam(answer.Text)
+	}
+	return msg, nil
+}
+
+func (c Command) confirm(bot gobot.Bot, msg gobot.Message) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), askTimeout)
+	defer cancel()
+	ok, err := gobot.Confirm(ctx, bot, msg, c.Confirm)
+	if err == context.DeadlineExceeded {
+		return false, ErrNoAnswer
+	}
+	return ok, err
+}
+
+func quoteParam(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+func (c Command) newExecutor(bot gobot.Bot, msg gobot.Message, logFields []interface{}) (*Executor, error) {
 	_, paramString := c.match(msg.Text)
 	params, err := c.parseParams(paramString)
 	if err != nil {
-		bot.SendMessage(fmt.Sprintf(errMsgFmt, err.Error()), msg.ChannelID)
+		c.replyRich(bot, msg, errorMessage(msg, msg.TErr(err)))
 		return nil, err
 	}
 
@@ -65,48 +174,96 @@ func (c Command) newExecutor(bot gobot.Bot, msg gobot.Message) (*Executor, error
 		return nil, err
 	}
 	if !c.hasPermission(channel, user) {
-		bot.SendMessage(fmt.Sprintf(errMsgFmt, "you are not allowed to do that"), msg.ChannelID)
+		c.replyRich(bot, msg, errorMessage(msg, msg.T("command.forbidden")))
 		return nil, ErrNoPermission
 	}
 
-	executor, err := NewExecutor(c, params)
+	executor, err := NewExecutor(c, params, logFields)
 	if err != nil {
 		return nil, err
 	}
 
+	executor.hooks.Add(2)
+
 	// post_slack hook
 	go func(e *Executor, msg gobot.Message) {
+		defer e.hooks.Done()
+		defer recoverHook(bot, msg, "post_slack hook")
 		for {
 			slackMsg, ok := e.NextSlackMessage()
 			if !ok {
 				break
 			}
-			bot.SendMessage(slackMsg, msg.ChannelID)
+			// posted without waiting, the executor drops what isn't taken in time
+			out := c.outgoing(msg)
+			out.Text = slackMsg
+			bot.Post(out)
 		}
 	}(executor, msg)
 
 	// error message hook
 	go func(e *Executor, c Command) {
-		errChannelID := c.ErrChannelID
-		if len(errChannelID) == 0 {
-			errChannelID = msg.ChannelID
-		}
-
+		defer e.hooks.Done()
+		defer recoverHook(bot, msg, "error message hook")
 		for {
 			errMsg, ok := e.NextErrorMessage()
 			if !ok {
 				break
 			}
-			errMsg = strings.TrimSuffix(errMsg, "\n")
-			bot.SendMessage(fmt.Sprintf(errMsgFmt, errMsg), errChannelID)
+			errRichMsg := errorMessage(msg, strings.TrimSuffix(errMsg, "\n"))
+			out := c.outgoing(msg)
+			if len(c.ErrChannelID) > 0 {
+				out = gobot.OutgoingMessage{ChannelID: c.ErrChannelID}
+			}
+			out.Rich = &errRichMsg
+			bot.Post(out)
 		}
 	}(executor, c)
 
 	return executor, nil
 }
 
+func recoverHook(bot gobot.Bot, msg gobot.Message, name string) {
+	if r := recover(); r != nil {
+		bot.ReportPanic(fmt.Sprintf("%s: `%s` by <@%s>", name, msg.Text, msg.UserID), r, debug.Stack())
+	}
+}
+
+// outgoing addresses a message to the thread of msg if the command is configured so, or to its channel otherwise.
+func (c Command) outgoing(msg gobot.Message) gobot.OutgoingMessage {
+	out := gobot.OutgoingMessage{ChannelID: msg.ChannelID}
+	if c.Thread {
+		out.ThreadTS = msg.Thread()
+	}
+	return out
+}
+
+func (c Command) reply(bot gobot.Bot, msg gobot.Message, text string) {
+	out := c.outgoing(msg)
+	out.Text = text
+	bot.Send(out)
+}
+
+func (c Command) replyRich(bot gobot.Bot, msg gobot.Message, richMsg gobot.RichMessage) {
+	out := c.outgoing(msg)
+	out.Rich = &richMsg
+	bot.Send(out)
+}
+
+// errorMessage is the card of an error replying to msg.
+func errorMessage(msg gobot.Message, text string) gobot.RichMessage {
+	return gobot.RichMessage{
+		Color: gobot.ColorDanger,
+		Sections: []gobot.Section{{
+			Title: msg.T("command.error"),
+			Text:  "```\n" + text + "\n```",
+		}},
+	}
+}
+
+// match tells whether text runs the command, its name is matched ignoring case, and returns the params.
 func (c Command) match(text string) (bool, string) {
-	if !strings.HasPrefix(text, c.Name) {
+	if len(text) < len(c.Name) || !strings.EqualFold(text[:len(c.Name)], c.Name) {
 		return false, ""
 	}
 	if len(text) == len(c.Name) {
@@ -119,6 +276,15 @@ func (c Command) match(text string) (bool, string) {
 	return true, text[1:]
 }
 
+func (c Command) isRequiredParamName(name string) bool {
+	for _, n := range c.RequiredParamNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (c Command) isValidParamName(name string) bool {
 	for _, n := range c.ParamNames {
 		if n == name {
@@ -98,6 +98,13 @@ func TestCommand_match(t *testing.T) {
 			want:    true,
 			want1:   "bbb ccc",
 		},
+		{
+			name:    "matched - ignoring case",
+			command: Command{Name: "aaa"},
+			args:    args{text: "AAA --bbb=CCC"},
+			want:    true,
+			want1:   "--bbb=CCC",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
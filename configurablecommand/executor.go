@@ -3,22 +3,34 @@ package configurablecommand
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/li-go/gobot/gobot"
 )
 
 const (
 	postSlackBegin = "post_slack_begin"
 	postSlackEnd   = "post_slack_end"
 	postSlack      = "#!/bin/sh\necho " + postSlackBegin + "\necho \"$@\"\necho " + postSlackEnd + "\n"
-	postSlackPath  = "/usr/local/bin/post_slack"
+	postSlackName  = "post_slack"
+)
+
+var (
+	ErrStopped = errors.New("command stopped before it started")
+
+	// readersTimeout is how long Wait lets the output be read once the command has exited,
+	// background processes it started may keep the pipes open long after
+	readersTimeout = 2 * time.Second
 )
 
 type Executor struct {
@@ -26,29 +38,34 @@ type Executor struct {
 	params  []param
 
 	cmd *exec.Cmd
+	// binDir holds post_slack, it's put first in the PATH of the command
+	binDir string
+	// pipes are the read ends of stdout and stderr, closed if the command never starts so the readers finish
+	pipes []io.Closer
 
 	logFile *os.File
 
 	slackMsgCh <-chan string
 	errMsgCh   <-chan string
+	// readers counts the goroutines reading the output, Wait lets them read it all
+	readers sync.WaitGroup
+	// hooks counts the goroutines posting the output to slack
+	hooks sync.WaitGroup
 
+	outputMutex sync.Mutex
+	lastOutput  string
+
+	// mutex guards stopped and the start of the command, so that Stop never sees it half started
+	mutex   sync.Mutex
 	stopped bool
+	// killed is closed by Stop, Wait doesn't wait for the output of killed commands
+	killed   chan struct{}
+	killOnce sync.Once
 }
 
-func NewExecutor(c Command, params []param) (*Executor, error) {
-	executor := &Executor{command: c, params: params}
-
-	// create command
-	if err := ioutil.WriteFile(postSlackPath, []byte(postSlack), 0777); err != nil {
-		// ignore error
-		log.Printf("warning: unable to create " + postSlackPath)
-	}
-	command := c.Command
-	for _, p := range params {
-		command += " --" + p.Name + " " + p.Value
-	}
-	cmd := exec.Command("bash", "-c", command)
-	executor.cmd = cmd
+// NewExecutor prepares the command, its output is logged to the log file of the command with logFields.
+func NewExecutor(c Command, params []param, logFields []interface{}) (*Executor, error) {
+	executor := &Executor{command: c, params: params, killed: make(chan struct{})}
 
 	// create log file
 	logFilename := c.LogFilename
@@ -57,18 +74,36 @@ func NewExecutor(c Command, params []param) (*Executor, error) {
 	}
 	logFilename, err := fullpath(logFilename)
 	if err != nil {
-		executor.clean()
 		return nil, err
 	}
 	logFile, err := os.OpenFile(logFilename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
 	if err != nil {
-		executor.clean()
 		return nil, fmt.Errorf("fail to open log logFile: %v", err)
 	}
 	executor.logFile = logFile
 
 	// create logger
-	logger := log.New(logFile, "", log.LstdFlags)
+	logger := gobot.NewLogger(logFile, gobot.LevelDebug, gobot.FormatLogfmt).With(logFields...)
+
+	// create command
+	command := c.Command
+	for _, p := range params {
+		command += " --" + p.Name
+		// flags have no value
+		if len(p.Value) > 0 {
+			command += " " + shellQuote(p.Value)
+		}
+	}
+	cmd := exec.Command("bash", "-c", command)
+	executor.cmd = cmd
+	if binDir, err := writePostSlack(); err != nil {
+		// ignore error
+		logger.Warn("unable to create "+postSlackName, "err", err)
+	} else {
+		executor.binDir = binDir
+		cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
 	slackMsgCh, err := executor.initStdoutPipe(cmd, logger)
 	if err != nil {
 		executor.clean()
@@ -85,15 +120,40 @@ func NewExecutor(c Command, params []param) (*Executor, error) {
 	return executor, nil
 }
 
+// shellQuote quotes s as one word of bash, e.g. a decoded `feature&fix` isn't run in the background.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 func (e *Executor) clean() {
 	if e.logFile != nil {
 		e.logFile.Close()
 	}
-	os.Remove(postSlackPath)
+	for _, pipe := range e.pipes {
+		pipe.Close()
+	}
+	if len(e.binDir) > 0 {
+		os.RemoveAll(e.binDir)
+	}
+}
+
+// writePostSlack writes post_slack into a new temporary directory, and returns the directory.
+func writePostSlack() (string, error) {
+	dir, err := ioutil.TempDir("", "gobot")
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, postSlackName), []byte(postSlack), 0777); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
 }
 
 func (e *Executor) Close() {
+	e.mutex.Lock()
 	e.stopped = true
+	e.mutex.Unlock()
 	e.clean()
 }
 
@@ -108,24 +168,28 @@ func fullpath(path string) (string, error) {
 	return strings.Replace(path, "~", u.HomeDir, 1), nil
 }
 
-func (e *Executor) initStdoutPipe(cmd *exec.Cmd, logger *log.Logger) (<-chan string, error) {
+func (e *Executor) initStdoutPipe(cmd *exec.Cmd, logger gobot.Logger) (<-chan string, error) {
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
 	}
+	e.pipes = append(e.pipes, stdout)
 
 	ch := make(chan string)
+	e.readers.Add(1)
 	go func(r io.ReadCloser, ch chan<- string) {
 		defer func() {
 			r.Close()
 			close(ch)
+			e.readers.Done()
 		}()
 
 		scanner := bufio.NewScanner(r)
 		var texts []string
-		for !e.stopped && scanner.Scan() {
+		for !e.IsStopped() && scanner.Scan() {
 			text := scanner.Text()
-			logger.Print(text)
+			logger.Info("output", "stream", "stdout", "line", text)
+			e.setLastOutput(text)
 
 			if text == postSlackBegin {
 				texts = []string{}
@@ -147,27 +211,31 @@ func (e *Executor) initStdoutPipe(cmd *exec.Cmd, logger *log.Logger) (<-chan str
 	return ch, nil
 }
 
-func (e *Executor) initStderrPipe(cmd *exec.Cmd, logger *log.Logger) (<-chan string, error) {
+func (e *Executor) initStderrPipe(cmd *exec.Cmd, logger gobot.Logger) (<-chan string, error) {
 	stderr, err := cmd.StderrPipe()
 	if err != nil {
 		return nil, err
 	}
+	e.pipes = append(e.pipes, stderr)
 
 	ch := make(chan string)
+	e.readers.Add(1)
 	go func(r io.ReadCloser, ch chan<- string) {
 		defer func() {
 			r.Close()
 			close(ch)
+			e.readers.Done()
 		}()
 
 		p := make([]byte, 10240)
-		for !e.stopped {
+		for !e.IsStopped() {
 			n, err := r.Read(p)
-			if err == io.EOF {
+			// cmd.Wait may close the pipe before EOF is read
+			if err != nil {
 				break
 			}
 			text := string(p[:n])
-			logger.Print(text)
+			logger.Warn("output", "stream", "stderr", "line", text)
 
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 			e.send(ctx, ch, text)
@@ -179,7 +247,7 @@ func (e *Executor) initStderrPipe(cmd *exec.Cmd, logger *log.Logger) (<-chan str
 }
 
 func (e *Executor) send(ctx context.Context, ch chan<- string, msg string) {
-	if e.stopped {
+	if e.IsStopped() {
 		return
 	}
 
@@ -191,6 +259,22 @@ func (e *Executor) send(ctx context.Context, ch chan<- string, msg string) {
 	}
 }
 
+func (e *Executor) setLastOutput(text string) {
+	if text == postSlackBegin || text == postSlackEnd || len(strings.TrimSpace(text)) == 0 {
+		return
+	}
+	e.outputMutex.Lock()
+	defer e.outputMutex.Unlock()
+	e.lastOutput = text
+}
+
+// LastOutput returns the last non-empty line the command wrote to stdout.
+func (e *Executor) LastOutput() string {
+	e.outputMutex.Lock()
+	defer e.outputMutex.Unlock()
+	return e.lastOutput
+}
+
 func (e *Executor) Command() string {
 	return e.cmd.Args[2]
 }
@@ -205,23 +289,71 @@ func (e *Executor) NextErrorMessage() (string, bool) {
 	return msg, ok
 }
 
+// Start starts the command unless it has been stopped already.
 func (e *Executor) Start() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.stopped {
+		return ErrStopped
+	}
 	return e.cmd.Start()
 }
 
+// Wait waits for the command to exit, then for its output to be read unless it's stopped meanwhile.
+// The pipes are closed once read or after readersTimeout, so background processes holding them don't block.
 func (e *Executor) Wait() error {
-	err := e.cmd.Wait()
-	if e.stopped {
+	if e.cmd.Process == nil {
+		return e.cmd.Wait()
+	}
+	state, err := e.cmd.Process.Wait()
+
+	read := make(chan struct{})
+	go func() {
+		e.readers.Wait()
+		close(read)
+	}()
+	timer := time.NewTimer(readersTimeout)
+	defer timer.Stop()
+	select {
+	case <-read:
+	case <-e.killed:
+	case <-timer.C:
+	}
+	for _, pipe := range e.pipes {
+		pipe.Close()
+	}
+
+	if e.IsStopped() {
 		return nil
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	if !state.Success() {
+		return &exec.ExitError{ProcessState: state}
+	}
+	return nil
 }
 
+// waitHooks waits for the output to be posted, the hooks finish once Wait has returned.
+func (e *Executor) waitHooks() {
+	e.hooks.Wait()
+}
+
+// Stop kills the command, a command not started yet is only marked stopped so that it never starts.
 func (e *Executor) Stop() error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	e.stopped = true
+	e.killOnce.Do(func() { close(e.killed) })
+	if e.cmd.Process == nil {
+		return nil
+	}
 	return e.cmd.Process.Kill()
 }
 
 func (e *Executor) IsStopped() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 	return e.stopped
 }
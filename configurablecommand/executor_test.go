@@ -0,0 +1,154 @@
+package configurablecommand
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// waitReaders fails the test if the output readers of e don't finish in time.
+func waitReaders(t *testing.T, e *Executor) {
+	done := make(chan struct{})
+	go func() {
+		e.readers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("output readers not finished")
+	}
+}
+
+func TestExecutor_Stop_notStarted(t *testing.T) {
+	e, err := NewExecutor(Command{Name: "echo", Command: "echo hello"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Stop(); err != nil {
+		t.Errorf("Stop() = %v, want nil", err)
+	}
+	if err := e.Start(); err != ErrStopped {
+		t.Errorf("Start() after Stop() = %v, want %v", err, ErrStopped)
+	}
+	e.Close()
+	waitReaders(t, e)
+}
+
+func TestTask_start(t *testing.T) {
+	killAt := time.Now()
+	tests := []struct {
+		name string
+		task *Task
+		want bool
+	}{
+		{name: "pending", task: &Task{}, want: true},
+		{name: "checkpointed", task: &Task{checkpointed: true}},
+		{name: "killed", task: &Task{killAt: &killAt}},
+	}
+	for _, tt := range tests {
+		e, err := NewExecutor(Command{Name: "true", Command: "true"}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		started, err := tt.task.start(e)
+		if err != nil || started != tt.want {
+			t.Errorf("%s: start() = %v, %v, want %v", tt.name, started, err, tt.want)
+		}
+		if started {
+			if err := e.Wait(); err != nil {
+				t.Errorf("%s: Wait() = %v", tt.name, err)
+			}
+		}
+		e.Close()
+		waitReaders(t, e)
+	}
+}
+
+func TestExecutor_Wait_background(t *testing.T) {
+	defer func(d time.Duration) { readersTimeout = d }(readersTimeout)
+	readersTimeout = 100 * time.Millisecond
+
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{command: "sleep 5 & echo started"},
+		{command: "sleep 5 & echo started; exit 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		e, err := NewExecutor(Command{Name: "bg", Command: tt.command}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		if err := e.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Wait(); (err != nil) != tt.wantErr {
+			t.Errorf("%q: Wait() = %v, want error %v", tt.command, err, tt.wantErr)
+		}
+		if d := time.Since(start); d > 2*time.Second {
+			t.Errorf("%q: Wait() took %v, want it not to wait for the background process", tt.command, d)
+		}
+		if got, want := e.LastOutput(), "started"; got != want {
+			t.Errorf("%q: LastOutput() = %q, want %q", tt.command, got, want)
+		}
+		e.Close()
+		waitReaders(t, e)
+	}
+}
+
+func TestExecutor_postSlack(t *testing.T) {
+	e, err := NewExecutor(Command{Name: "post", Command: "post_slack hello"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Start(); err != nil {
+		t.Fatal(err)
+	}
+	msg, ok := e.NextSlackMessage()
+	if !ok || msg != "hello" {
+		t.Errorf("NextSlackMessage() = %q, %v, want %q", msg, ok, "hello")
+	}
+	if err := e.Wait(); err != nil {
+		t.Errorf("Wait() = %v", err)
+	}
+	e.Close()
+	waitReaders(t, e)
+	if _, err := os.Stat(e.binDir); !os.IsNotExist(err) {
+		t.Errorf("%s not removed: %v", e.binDir, err)
+	}
+}
+
+func TestExecutor_quoteParams(t *testing.T) {
+	tests := []struct {
+		param param
+		want  string
+	}{
+		{param: param{Name: "branch", Value: "feature&fix"}, want: "2:feature&fix"},
+		{param: param{Name: "branch", Value: "it's"}, want: "2:it's"},
+		{param: param{Name: "branch", Value: "$(echo injected)"}, want: "2:$(echo injected)"},
+		{param: param{Name: "branch", Value: "a b"}, want: "2:a b"},
+		// a flag is one argument
+		{param: param{Name: "dry"}, want: "1:"},
+	}
+	for _, tt := range tests {
+		c := Command{Name: "args", Command: `args() { echo post_slack_begin; echo "$#:$2"; echo post_slack_end; }; args`}
+		e, err := NewExecutor(c, []param{tt.param}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := e.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := e.NextSlackMessage(); got != tt.want {
+			t.Errorf("--%s %s: script got %q, want %q", tt.param.Name, tt.param.Value, got, tt.want)
+		}
+		if err := e.Wait(); err != nil {
+			t.Errorf("--%s %s: Wait() = %v", tt.param.Name, tt.param.Value, err)
+		}
+		e.Close()
+		waitReaders(t, e)
+	}
+}
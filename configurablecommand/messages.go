@@ -0,0 +1,38 @@
+package configurablecommand
+
+import "github.com/li-go/gobot/gobot"
+
+func init() {
+	gobot.RegisterMessages(gobot.LangEnglish, gobot.Bundle{
+		"command.input":     "please input `--%s`:",
+		"command.forbidden": "you are not allowed to do that",
+		"command.error":     "error :thinking_face:",
+		"command.no_answer": "no answer",
+		"task.succeeded":    "<@%s> *succeeded* - `%s` :open_mouth:",
+		"task.stopped":      "*stopped* - `%s`",
+		"task.cancelled":    "cancelled - `%s`",
+		"task.running":      "Running %s… `%s`",
+		"task.output":       "last output: %s",
+		"task.not_found":    "task not found",
+		"field.task":        "Task",
+		"field.duration":    "Duration",
+		"button.rerun":      "Rerun",
+		"button.kill":       "Kill",
+	})
+	gobot.RegisterMessages(gobot.LangJapanese, gobot.Bundle{
+		"command.input":     "`--%s` を入力してください:",
+		"command.forbidden": "その操作は許可されていません",
+		"command.error":     "エラー :thinking_face:",
+		"command.no_answer": "回答がありませんでした",
+		"task.succeeded":    "<@%s> *成功* - `%s` :open_mouth:",
+		"task.stopped":      "*停止* - `%s`",
+		"task.cancelled":    "キャンセルしました - `%s`",
+		"task.running":      "実行中 %s… `%s`",
+		"task.output":       "最後の出力: %s",
+		"task.not_found":    "タスクが見つかりません",
+		"field.task":        "タスク",
+		"field.duration":    "所要時間",
+		"button.rerun":      "再実行",
+		"button.kill":       "停止",
+	})
+}
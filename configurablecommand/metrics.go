@@ -0,0 +1,38 @@
+package configurablecommand
+
+import "github.com/li-go/gobot/metrics"
+
+var (
+	taskDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+
+	taskDuration = metrics.Default.NewHistogramVec("gobot_task_duration_seconds", "Durations of finished tasks by command.", taskDurationBuckets, "command")
+)
+
+func init() {
+	metrics.Default.NewGaugeFunc("gobot_tasks", "Tasks by status.", []string{"status"}, taskCounts)
+	metrics.Default.NewGaugeFunc("gobot_task_queue_depth", "Pending tasks waiting to be started.", nil, func() []metrics.Sample {
+		mutex.RLock()
+		defer mutex.RUnlock()
+		var n int
+		for _, t := range tasks {
+			if t.Status() == Pending {
+				n++
+			}
+		}
+		return []metrics.Sample{{Value: float64(n)}}
+	})
+}
+
+func taskCounts() []metrics.Sample {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	counts := make(map[TaskStatus]int)
+	for _, t := range tasks {
+		counts[t.Status()]++
+	}
+	var samples []metrics.Sample
+	for s := Pending; s <= Failed; s++ {
+		samples = append(samples, metrics.Sample{LabelValues: []string{s.String()}, Value: float64(counts[s])})
+	}
+	return samples
+}
@@ -0,0 +1,85 @@
+package configurablecommand
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	progressInterval = 10 * time.Second
+)
+
+// progress keeps one live message of a running task updated with the elapsed time and the last output.
+type progress struct {
+	task     *Task
+	executor *Executor
+	ref      gobot.MessageRef
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// startProgress posts the live message if the command is configured so, it returns nil otherwise.
+func startProgress(t *Task, e *Executor) *progress {
+	if !t.cmd.Progress {
+		return nil
+	}
+	p := &progress{task: t, executor: e, done: make(chan struct{}), stopped: make(chan struct{})}
+	ref, err := t.bot.Send(p.message(t.cmd.outgoing(t.Msg)))
+	if err != nil {
+		return nil
+	}
+	p.ref = ref
+	go p.run()
+	return p
+}
+
+func (p *progress) run() {
+	defer close(p.stopped)
+	tick := time.NewTicker(progressInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-tick.C:
+			_ = p.task.bot.UpdateMessage(p.ref, p.message(gobot.OutgoingMessage{}))
+		}
+	}
+}
+
+// message fills out with the live message, which has a button killing the task.
+func (p *progress) message(out gobot.OutgoingMessage) gobot.OutgoingMessage {
+	out.Text = p.text()
+	out.Rich = &gobot.RichMessage{
+		Sections: []gobot.Section{{Text: out.Text}},
+		Buttons:  []gobot.Button{{Text: p.task.Msg.T("button.kill"), Value: fmt.Sprintf("kill %d", p.task.ID), Style: gobot.ButtonDanger}},
+	}
+	return out
+}
+
+func (p *progress) text() string {
+	text := p.task.Msg.T("task.running", p.task.Duration().Round(time.Second), p.task.Msg.Text)
+	if output := p.executor.LastOutput(); len(output) > 0 {
+		text += " " + p.task.Msg.T("task.output", output)
+	}
+	return text
+}
+
+// finish stops updating and replaces the live message with msg.
+func (p *progress) finish(msg gobot.OutgoingMessage) {
+	close(p.done)
+	<-p.stopped
+	_ = p.task.bot.UpdateMessage(p.ref, msg)
+}
+
+// report posts the final status of the task, into the live message if there is one.
+func (t *Task) report(p *progress, richMsg gobot.RichMessage) {
+	if p == nil {
+		t.cmd.replyRich(t.bot, t.Msg, richMsg)
+		return
+	}
+	p.finish(gobot.OutgoingMessage{Rich: &richMsg})
+}
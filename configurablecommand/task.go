@@ -3,6 +3,9 @@ package configurablecommand
 import (
 	"errors"
 	"fmt"
+	"runtime/debug"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/li-go/gobot/gobot"
@@ -19,10 +22,20 @@ const (
 	Failed
 )
 
+const (
+	reactionAccepted  = "eyes"
+	reactionSucceeded = "white_check_mark"
+	reactionFailed    = "x"
+)
+
 var (
 	ErrNoKillPermission = errors.New("no kill permission")
 )
 
+// executorMutex guards the executors of the tasks and whether they are checkpointed,
+// Kill and Shutdown stop tasks from other goroutines than the ones running them.
+var executorMutex sync.Mutex
+
 type Task struct {
 	ID  int
 	Msg gobot.Message
@@ -35,7 +48,10 @@ type Task struct {
 	startAt  *time.Time
 	finishAt *time.Time
 
+	// executor and checkpointed are guarded by executorMutex
 	executor *Executor
+	// checkpointed tells the task was stopped by Shutdown to be restarted next time
+	checkpointed bool
 
 	err error
 }
@@ -61,13 +77,20 @@ func (t *Task) Active() bool {
 }
 
 func (t *Task) Start() {
+	defer t.recoverPanic()
+
+	// cancelled after scheduled
+	if t.Status() == Killed {
+		return
+	}
+
 	now1 := time.Now()
 	t.startAt = &now1
 	saveTask(t)
 
 	err := t.execute()
 
-	if t.Status() == Killed || stoppingAll {
+	if t.Status() == Killed || t.isCheckpointed() {
 		return
 	}
 
@@ -75,6 +98,34 @@ func (t *Task) Start() {
 	t.finishAt = &now2
 	t.err = err
 	saveTask(t)
+	t.logger().Info("task finished", "status", t.Status(), "duration", t.Duration(), "err", err)
+	taskDuration.Observe(t.Duration().Seconds(), t.cmd.Name)
+
+	unreact(t.bot, t.Msg, reactionAccepted)
+	if err == nil {
+		react(t.bot, t.Msg, reactionSucceeded)
+	} else {
+		react(t.bot, t.Msg, reactionFailed)
+	}
+}
+
+// recoverPanic fails the task on panic and reports it instead of crashing the bot.
+func (t *Task) recoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	t.bot.ReportPanic(fmt.Sprintf("task #%d: `%s` by <@%s>", t.ID, t.Msg.Text, t.Msg.UserID), r, debug.Stack())
+	if t.Status() != Running {
+		return
+	}
+	now := time.Now()
+	t.finishAt = &now
+	t.err = fmt.Errorf("panic: %v", r)
+	saveTask(t)
+	taskDuration.Observe(t.Duration().Seconds(), t.cmd.Name)
+	unreact(t.bot, t.Msg, reactionAccepted)
+	react(t.bot, t.Msg, reactionFailed)
 }
 
 func (t *Task) Kill(userID string) error {
@@ -82,15 +133,74 @@ func (t *Task) Kill(userID string) error {
 		return ErrNoKillPermission
 	}
 
-	if t.Status() == Running && t.executor != nil {
-		t.err = t.executor.Stop()
-	}
+	executorMutex.Lock()
+	executor := t.executor
+	// the task is killed from now on, whether it was running is told before
+	running := t.Status() == Running
 	now := time.Now()
 	t.killAt = &now
+	executorMutex.Unlock()
+	if executor != nil && running {
+		t.err = executor.Stop()
+	}
 	saveTask(t)
+	t.logger().Info("task killed")
+
+	unreact(t.bot, t.Msg, reactionAccepted)
 	return nil
 }
 
+// cancel kills the pending task, e.g. when the message it was queued by is deleted.
+func (t *Task) cancel() {
+	now := time.Now()
+	t.killAt = &now
+	saveTask(t)
+	t.logger().Info("task cancelled")
+	unreact(t.bot, t.Msg, reactionAccepted)
+}
+
+// checkpoint stops the running task and leaves it running in the store, so that LoadPendingTasks restarts it.
+func (t *Task) checkpoint() {
+	executorMutex.Lock()
+	t.checkpointed = true
+	executor := t.executor
+	executorMutex.Unlock()
+	if executor != nil {
+		_ = executor.Stop()
+	}
+	t.logger().Info("task stopped for shutdown")
+}
+
+func (t *Task) isCheckpointed() bool {
+	executorMutex.Lock()
+	defer executorMutex.Unlock()
+	return t.checkpointed
+}
+
+// start starts the command of executor unless the task has been checkpointed or killed meanwhile,
+// from then on Kill and checkpoint stop it.
+func (t *Task) start(executor *Executor) (bool, error) {
+	executorMutex.Lock()
+	defer executorMutex.Unlock()
+	if t.checkpointed || t.killAt != nil {
+		return false, nil
+	}
+	if err := executor.Start(); err != nil {
+		return false, err
+	}
+	t.executor = executor
+	return true, nil
+}
+
+// logFields correlates the log lines of the task with the ones of the message it was queued by.
+func (t *Task) logFields() []interface{} {
+	return append(t.Msg.LogFields(), "task", t.ID)
+}
+
+func (t *Task) logger() gobot.Logger {
+	return t.bot.GetLogger().With(t.logFields()...)
+}
+
 func (t *Task) Duration() time.Duration {
 	switch t.Status() {
 	case Pending:
@@ -109,18 +219,34 @@ func (t *Task) Duration() time.Duration {
 	}
 }
 
+// react acknowledges msg with an emoji reaction, messages restored without timestamp are skipped.
+func react(bot gobot.Bot, msg gobot.Message, name string) {
+	if len(msg.TS) > 0 {
+		_ = bot.AddReaction(name, msg.Ref())
+	}
+}
+
+func unreact(bot gobot.Bot, msg gobot.Message, name string) {
+	if len(msg.TS) > 0 {
+		_ = bot.RemoveReaction(name, msg.Ref())
+	}
+}
+
 func (t *Task) execute() error {
 	bot := t.bot
 	msg := t.Msg
 
-	executor, err := t.cmd.newExecutor(bot, msg)
+	logFields := t.logFields()
+	// the log file of the command is shared by the workspaces
+	if len(msg.Workspace) > 0 {
+		logFields = append(logFields, "workspace", msg.Workspace)
+	}
+	executor, err := t.cmd.newExecutor(bot, msg, logFields)
 	if err != nil {
 		return err
 	}
 	defer executor.Close()
 
-	t.executor = executor
-
 	// execute
 	channel, err := bot.LoadChannel(msg.ChannelID)
 	if err != nil {
@@ -130,16 +256,47 @@ func (t *Task) execute() error {
 	if err != nil {
 		return err
 	}
-	bot.GetLogger().Printf("%s is executing `%s` in %s - #%d", user, executor.Command(), channel, t.ID)
-	if err := executor.Start(); err != nil {
-		bot.SendMessage(fmt.Sprintf(errMsgFmt, err.Error()), msg.ChannelID)
+	t.logger().Info("executing", "command", executor.Command(), "user_name", user, "channel_name", channel)
+	started, err := t.start(executor)
+	if err != nil {
+		t.cmd.replyRich(bot, msg, errorMessage(msg, msg.TErr(err)))
 		return err
 	}
-	if err := executor.Wait(); err != nil {
+	if !started {
+		return nil
+	}
+	progress := startProgress(t, executor)
+	err = executor.Wait()
+	// the output is posted before the status
+	executor.waitHooks()
+	if err != nil {
+		t.report(progress, t.statusMessage(err))
 		return err
 	}
-	if !executor.IsStopped() {
-		bot.SendMessage(fmt.Sprintf("<@%s> *succeeded* - `%s` :open_mouth:", msg.UserID, msg.Text), msg.ChannelID)
+	if executor.IsStopped() {
+		if progress != nil {
+			progress.finish(gobot.OutgoingMessage{Text: msg.T("task.stopped", msg.Text)})
+		}
+		return nil
 	}
+	t.report(progress, t.statusMessage(nil))
 	return nil
 }
+
+// statusMessage is the card posted when the task finishes with err.
+func (t *Task) statusMessage(err error) gobot.RichMessage {
+	section := gobot.Section{
+		Text: t.Msg.T("task.succeeded", t.Msg.UserID, t.Msg.Text),
+		Fields: []gobot.Field{
+			{Title: t.Msg.T("field.task"), Value: "#" + strconv.Itoa(t.ID)},
+			{Title: t.Msg.T("field.duration"), Value: (t.Duration() / time.Millisecond * time.Millisecond).String()},
+		},
+	}
+	rerun := []gobot.Button{{Text: t.Msg.T("button.rerun"), Value: t.Msg.Text}}
+	if err == nil {
+		return gobot.RichMessage{Color: gobot.ColorGood, Sections: []gobot.Section{section}, Buttons: rerun}
+	}
+	section.Text = t.Msg.T("failed", t.Msg.UserID, t.Msg.Text)
+	section.Fields = append(section.Fields, gobot.Field{Title: t.Msg.T("field.error"), Value: t.Msg.TErr(err)})
+	return gobot.RichMessage{Color: gobot.ColorDanger, Sections: []gobot.Section{section}, Buttons: rerun}
+}
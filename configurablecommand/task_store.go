@@ -16,6 +16,12 @@ type TaskEntity struct {
 	MsgText      string        `db:"msg_text"`
 	MsgChannelID string        `db:"msg_channel_id"`
 	MsgUserID    string        `db:"msg_user_id"`
+	MsgTS        string        `db:"msg_ts"`
+	MsgThreadTS  string        `db:"msg_thread_ts"`
+	MsgWorkspace string        `db:"msg_workspace"`
+	MsgLang      string        `db:"msg_lang"`
+	// MsgCorrelationID keeps the log lines of restarted tasks correlated
+	MsgCorrelationID string `db:"msg_correlation_id"`
 
 	CmdJson string `db:"cmd_json" gorm:"type:text"`
 
@@ -38,17 +44,22 @@ func NewTaskEntity(task *Task) (*TaskEntity, error) {
 		errMsg = &s
 	}
 	return &TaskEntity{
-		ID:           task.ID,
-		MsgType:      task.Msg.Type,
-		MsgText:      task.Msg.Text,
-		MsgChannelID: task.Msg.ChannelID,
-		MsgUserID:    task.Msg.UserID,
-		CmdJson:      string(buf),
-		RunAt:        task.runAt,
-		KillAt:       task.killAt,
-		StartAt:      task.startAt,
-		FinishAt:     task.finishAt,
-		ErrMsg:       errMsg,
+		ID:               task.ID,
+		MsgType:          task.Msg.Type,
+		MsgText:          task.Msg.Text,
+		MsgChannelID:     task.Msg.ChannelID,
+		MsgUserID:        task.Msg.UserID,
+		MsgTS:            task.Msg.TS,
+		MsgThreadTS:      task.Msg.ThreadTS,
+		MsgWorkspace:     task.Msg.Workspace,
+		MsgLang:          task.Msg.Lang,
+		MsgCorrelationID: task.Msg.CorrelationID,
+		CmdJson:          string(buf),
+		RunAt:            task.runAt,
+		KillAt:           task.killAt,
+		StartAt:          task.startAt,
+		FinishAt:         task.finishAt,
+		ErrMsg:           errMsg,
 	}, nil
 }
 
@@ -68,6 +79,12 @@ func (entity *TaskEntity) Task() (*Task, error) {
 			Text:      entity.MsgText,
 			ChannelID: entity.MsgChannelID,
 			UserID:    entity.MsgUserID,
+			TS:        entity.MsgTS,
+			ThreadTS:  entity.MsgThreadTS,
+			Workspace: entity.MsgWorkspace,
+			Lang:      entity.MsgLang,
+
+			CorrelationID: entity.MsgCorrelationID,
 		},
 		cmd:      cmd,
 		runAt:    entity.RunAt,
@@ -110,3 +127,13 @@ func (store *taskStore) All() ([]TaskEntity, error) {
 	err := store.repo.GetAll(TaskEntity{}, &ee)
 	return ee, err
 }
+
+// CheckStore returns an error if the task store can't be opened or queried.
+func CheckStore() error {
+	store, err := newTaskStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return store.repo.Ping()
+}
@@ -1,10 +1,12 @@
 package configurablecommand
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"sort"
-	"strconv"
 	"sync"
 	"time"
 
@@ -13,6 +15,10 @@ import (
 
 const (
 	maxTasks = 10
+
+	scheduleInterval = time.Second
+	// the scheduler is considered stuck when it hasn't ticked for schedulerStuckAfter
+	schedulerStuckAfter = 10 * scheduleInterval
 )
 
 var (
@@ -21,11 +27,23 @@ var (
 	mutex      sync.RWMutex
 
 	ErrTooManyTasks = errors.New("too many tasks")
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound = gobot.NewError("task.not_found")
+
+	ErrSchedulerStopped = errors.New("scheduler stopped")
+	ErrSchedulerStuck   = errors.New("scheduler stuck")
 
 	stoppingAll bool
+	// runningTasks counts the started tasks until they return
+	runningTasks sync.WaitGroup
+	// lastScheduled is when the scheduler ticked last time
+	lastScheduled time.Time
+	// schedulerStarted is set by StartScheduler, tasks are run by RunPendingTasks only until then
+	schedulerStarted bool
+	schedulerOnce    sync.Once
 )
 
+// LoadPendingTasks restores the pending and running tasks of the workspace of bot.
+// It's called once per bot, task IDs are unique across workspaces.
 func LoadPendingTasks(bot gobot.Bot) {
 	// ignore errors
 	store, err := newTaskStore()
@@ -39,10 +57,16 @@ func LoadPendingTasks(bot gobot.Bot) {
 	sort.Slice(taskEntities, func(i, j int) bool {
 		return taskEntities[i].ID < taskEntities[j].ID
 	})
-	if len(taskEntities) > 0 {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(taskEntities) > 0 && taskEntities[len(taskEntities)-1].ID > lastTaskID {
 		lastTaskID = taskEntities[len(taskEntities)-1].ID
 	}
 	for _, e := range taskEntities {
+		if e.MsgWorkspace != bot.Workspace() {
+			continue
+		}
 		task, err := e.Task()
 		if err != nil {
 			continue
@@ -73,35 +97,57 @@ func saveTask(t *Task) {
 	_ = store.Save(*entity)
 }
 
-func GetTasks() []Task {
+// GetTasks returns the tasks of workspace.
+func GetTasks(workspace string) []Task {
 	mutex.RLock()
 	defer mutex.RUnlock()
 	var tt []Task
 	for _, t := range tasks {
+		if t.Msg.Workspace != workspace {
+			continue
+		}
 		tt = append(tt, *t)
 	}
 	return tt
 }
 
-func FindTask(id int) (*Task, error) {
+// FindTask finds the task of workspace by ID, the tasks of other workspaces are not found.
+func FindTask(workspace string, id int) (*Task, error) {
 	mutex.RLock()
 	defer mutex.RUnlock()
 	for _, t := range tasks {
-		if t.ID == id {
+		if t.ID == id && t.Msg.Workspace == workspace {
 			return t, nil
 		}
 	}
 	return nil, ErrTaskNotFound
 }
 
-func addTask(bot gobot.Bot, msg gobot.Message, cmd Command) error {
+// CancelTasks cancels the pending tasks of workspace queued by the message ref, and returns them.
+// Running tasks are left alone, they are killed by the kill command.
+func CancelTasks(workspace string, ref gobot.MessageRef) []*Task {
+	mutex.Lock()
+	defer mutex.Unlock()
+	var cancelled []*Task
+	for _, t := range tasks {
+		if t.Msg.Workspace != workspace || t.Msg.Ref() != ref || t.Status() != Pending {
+			continue
+		}
+		t.cancel()
+		cancelled = append(cancelled, t)
+	}
+	return cancelled
+}
+
+// addTask queues a task of cmd for msg and returns its ID.
+func addTask(bot gobot.Bot, msg gobot.Message, cmd Command) (int, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	if len(tasks) >= maxTasks {
-		removeTask()
+	if countTasks(msg.Workspace) >= maxTasks {
+		removeTask(msg.Workspace)
 	}
-	if len(tasks) >= maxTasks {
-		return ErrTooManyTasks
+	if countTasks(msg.Workspace) >= maxTasks {
+		return 0, ErrTooManyTasks
 	}
 
 	task := &Task{
@@ -114,16 +160,28 @@ func addTask(bot gobot.Bot, msg gobot.Message, cmd Command) error {
 	lastTaskID++
 	tasks = append(tasks, task)
 	saveTask(task)
-	return nil
+	return task.ID, nil
+}
+
+// countTasks counts the tasks of workspace, each workspace keeps at most maxTasks.
+func countTasks(workspace string) int {
+	var n int
+	for _, t := range tasks {
+		if t.Msg.Workspace == workspace {
+			n++
+		}
+	}
+	return n
 }
 
-func removeTask() *Task {
+// removeTask removes the oldest finished task of workspace.
+func removeTask(workspace string) *Task {
 	if len(tasks) == 0 {
 		return nil
 	}
 
 	for i, task := range tasks {
-		if task.Active() {
+		if task.Active() || task.Msg.Workspace != workspace {
 			continue
 		}
 		var newTasks []*Task
@@ -136,7 +194,7 @@ func removeTask() *Task {
 }
 
 // nextExecutableTask looks for pending task,
-//  there should be no running task with same type (same name) of command
+//  there should be no running task with same type (same name) of command, in any workspace
 func nextExecutableTask() *Task {
 	var runningTasks []*Task
 	var pendingTasks []*Task
@@ -162,30 +220,140 @@ func nextExecutableTask() *Task {
 	return nil
 }
 
-func StopAll() {
+// Shutdown stops scheduling tasks and stops the running ones, except those configured to be waited for.
+// Stopped tasks stay running in the store and are restarted by LoadPendingTasks next time.
+// It waits for the tasks until ctx is done, then stops the remaining ones too.
+func Shutdown(ctx context.Context) error {
+	mutex.Lock()
 	stoppingAll = true
+	running := runningTaskList()
+	mutex.Unlock()
+
+	for _, t := range running {
+		if t.cmd.OnShutdown != OnShutdownWait {
+			t.checkpoint()
+		}
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		runningTasks.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		mutex.RLock()
+		running = runningTaskList()
+		mutex.RUnlock()
+		for _, t := range running {
+			t.checkpoint()
+		}
+		return ctx.Err()
+	}
+}
+
+func runningTaskList() []*Task {
+	var running []*Task
 	for _, t := range tasks {
 		if t.Status() == Running {
-			t.executor.Stop()
-			log.Print("stopped task #" + strconv.Itoa(t.ID))
+			running = append(running, t)
 		}
 	}
+	return running
 }
 
-func init() {
-	// how to stop
-	go func() {
-		tick := time.NewTicker(time.Second)
-		defer tick.Stop()
-		for {
-			<-tick.C
-			if stoppingAll {
-				break
+// CheckScheduler returns an error if the scheduler isn't started, has been stopped or hasn't ticked recently.
+func CheckScheduler() error {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if !schedulerStarted || stoppingAll {
+		return ErrSchedulerStopped
+	}
+	if since := time.Since(lastScheduled); since > schedulerStuckAfter {
+		return fmt.Errorf("%w: last tick %s ago", ErrSchedulerStuck, since.Round(time.Second))
+	}
+	return nil
+}
+
+// RunPendingTasks runs the executable tasks one by one until none is left, and returns how many it ran.
+// Tasks queued meanwhile are run too. It's meant to finish them deterministically in tests,
+// where the scheduler isn't started.
+func RunPendingTasks() int {
+	var n int
+	for {
+		mutex.Lock()
+		if stoppingAll {
+			mutex.Unlock()
+			return n
+		}
+		t := nextExecutableTask()
+		if t == nil {
+			mutex.Unlock()
+			return n
+		}
+		runningTasks.Add(1)
+		mutex.Unlock()
+
+		t.Start()
+		runningTasks.Done()
+		n++
+	}
+}
+
+func isStoppingAll() bool {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return stoppingAll
+}
+
+// StartScheduler starts a pending task every tick until Shutdown, it's called once the bots are made.
+func StartScheduler() {
+	schedulerOnce.Do(func() {
+		mutex.Lock()
+		schedulerStarted = true
+		lastScheduled = time.Now()
+		mutex.Unlock()
+
+		go func() {
+			tick := time.NewTicker(scheduleInterval)
+			defer tick.Stop()
+			for range tick.C {
+				if isStoppingAll() {
+					break
+				}
+				schedule()
 			}
-			t := nextExecutableTask()
-			if t != nil {
-				go t.Start()
+		}()
+	})
+}
+
+// schedule starts the next executable task, a panic is reported and the scheduler keeps ticking.
+func schedule() {
+	var t *Task
+	defer func() {
+		if r := recover(); r != nil {
+			if t != nil && t.bot != nil {
+				t.bot.ReportPanic(fmt.Sprintf("scheduling task #%d", t.ID), r, debug.Stack())
+				return
 			}
+			log.Printf("panic in scheduler: %v\n%s", r, debug.Stack())
 		}
 	}()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	lastScheduled = time.Now()
+	if stoppingAll {
+		return
+	}
+	t = nextExecutableTask()
+	if t != nil {
+		runningTasks.Add(1)
+		go func(t *Task) {
+			defer runningTasks.Done()
+			t.Start()
+		}(t)
+	}
 }
@@ -0,0 +1,58 @@
+package configurablecommand
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+func TestTasks_workspace(t *testing.T) {
+	// killed so that the scheduler leaves them alone
+	killAt := time.Now()
+	mutex.Lock()
+	saved := tasks
+	tasks = []*Task{
+		{ID: 1, Msg: gobot.Message{Workspace: "team-a"}, killAt: &killAt},
+		{ID: 2, Msg: gobot.Message{Workspace: "team-b"}, killAt: &killAt},
+		{ID: 3, Msg: gobot.Message{Workspace: "team-a"}, killAt: &killAt},
+	}
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		tasks = saved
+		mutex.Unlock()
+	}()
+
+	tests := []struct {
+		workspace string
+		wantIDs   []int
+	}{
+		{workspace: "team-a", wantIDs: []int{1, 3}},
+		{workspace: "team-b", wantIDs: []int{2}},
+		{workspace: "", wantIDs: nil},
+	}
+	for _, tt := range tests {
+		var ids []int
+		for _, task := range GetTasks(tt.workspace) {
+			ids = append(ids, task.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.wantIDs) {
+			t.Errorf("GetTasks(%q) IDs = %v, want %v", tt.workspace, ids, tt.wantIDs)
+		}
+		mutex.RLock()
+		got := countTasks(tt.workspace)
+		mutex.RUnlock()
+		if got != len(tt.wantIDs) {
+			t.Errorf("countTasks(%q) = %v, want %v", tt.workspace, got, len(tt.wantIDs))
+		}
+	}
+
+	if _, err := FindTask("team-b", 1); err != ErrTaskNotFound {
+		t.Errorf("FindTask(team-b, 1) error = %v, want %v", err, ErrTaskNotFound)
+	}
+	if task, err := FindTask("team-a", 1); err != nil || task.ID != 1 {
+		t.Errorf("FindTask(team-a, 1) = %v, %v, want task 1", task, err)
+	}
+}
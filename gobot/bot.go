@@ -1,60 +1,175 @@
 package gobot
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"runtime/debug"
+	"strconv"
 	"strings"
-
-	"github.com/nlopes/slack"
+	"sync"
+	"time"
 
 	"github.com/li-go/gobot/ai"
 )
 
+const (
+	maxStackLength = 3000
+)
+
 var (
 	ErrInvalidHandler    = errors.New("invalid handler")
 	ErrDuplicateRegister = errors.New("duplicate register")
+	ErrStopped           = errors.New("bot stopped")
 )
 
 type Bot interface {
 	RegisterHandler(Handler) error
+	RegisterEventHandler(EventHandler) error
+	Use(...Middleware)
 	Start()
 	Stop()
-	GetRTM() *slack.RTM
-	GetLogger() *log.Logger
-	SendMessage(string, string)
+	Shutdown(context.Context) error
+	GetTransport() Transport
+	GetLogger() Logger
+	Health() Health
+	SendMessage(string, string) (MessageRef, error)
+	ReplyMessage(string, Message) (MessageRef, error)
+	Ask(context.Context, Message, string) (Message, error)
+	Send(OutgoingMessage) (MessageRef, error)
+	Post(OutgoingMessage)
+	UpdateMessage(MessageRef, OutgoingMessage) error
+	DeleteMessage(MessageRef) error
+	AddReaction(string, MessageRef) error
+	RemoveReaction(string, MessageRef) error
 	LoadChannel(string) (string, error)
 	LoadUser(string) (string, error)
-	Help() string
+	Help(string) string
+	SetUserLanguage(string, string)
+	Workspace() string
+	Language(string) string
+	ReportPanic(string, interface{}, []byte)
 }
 
 type bot struct {
-	rtm       *slack.RTM
-	logger    *log.Logger
-	msgParser *MessageParser
-	user      string
-	channels  map[string]string
-	users     map[string]string
+	transport      Transport
+	logger         Logger
+	msgParser      *MessageParser
+	userID         string
+	user           string
+	adminChannelID string
+	workspace      string
+	channels       *directory
+	users          *directory
+	received       *received
+	outbox         *outbox
+	languages      *languages
+
+	handlers      []Handler
+	eventHandlers []EventHandler
+	middlewares   []Middleware
+
+	conversations conversations
+
+	// connected counts the connections of the transport, the ones after the first are reconnects
+	connected int
+	health    health
+
+	// inflight counts the running handlers, they are spawned only until the bot is stopped
+	mutex    sync.Mutex
+	stopped  bool
+	done     chan struct{}
+	inflight sync.WaitGroup
+	running  int
+}
+
+type Option func(*options)
+
+type options struct {
+	workspace      string
+	adminChannelID string
+	editWindow     time.Duration
+	language       string
+	triggers       Triggers
+	sendInterval   time.Duration
+	sendBurst      int
+}
+
+// OptionWorkspace names the slack workspace the bot is connected to, it's needed only when running several bots.
+func OptionWorkspace(name string) Option {
+	return func(o *options) {
+		o.workspace = name
+	}
+}
+
+// OptionAdminChannel makes the bot report panics to the channel.
+func OptionAdminChannel(channelID string) Option {
+	return func(o *options) {
+		o.adminChannelID = channelID
+	}
+}
+
+// OptionEditWindow makes the bot dispatch the messages edited within window after they are received again,
+// edits are ignored by default.
+func OptionEditWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.editWindow = window
+	}
+}
+
+// OptionLanguage selects the language of replies in the workspace, it's DefaultLanguage unless given.
+func OptionLanguage(lang string) Option {
+	return func(o *options) {
+		if len(lang) > 0 {
+			o.language = lang
+		}
+	}
+}
+
+// OptionTriggers configures the ways to address the bot besides starting a message with a mention of it.
+func OptionTriggers(triggers Triggers) Option {
+	return func(o *options) {
+		o.triggers = triggers
+	}
+}
 
-	handlers []Handler
+// OptionSendRate lets each channel get burst messages at once, then one every interval.
+func OptionSendRate(interval time.Duration, burst int) Option {
+	return func(o *options) {
+		o.sendInterval = interval
+		o.sendBurst = burst
+	}
+}
 
-	stopped bool
+func newOptions(opts []Option) options {
+	o := options{language: DefaultLanguage, sendInterval: sendInterval, sendBurst: sendBurst}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
-func New(token string, logger *log.Logger) (Bot, error) {
-	rtm := slack.New(token).NewRTM()
-	res, err := rtm.AuthTest()
+func New(transport Transport, logger Logger, opts ...Option) (Bot, error) {
+	o := newOptions(opts)
+	identity, err := transport.Connect()
 	if err != nil {
 		return nil, err
 	}
 
 	return &bot{
-		rtm:       rtm,
-		logger:    logger,
-		msgParser: NewMessageParser(res.UserID),
-		user:      "@" + res.User,
-		channels:  make(map[string]string),
-		users:     make(map[string]string),
+		transport:      transport,
+		logger:         logger,
+		msgParser:      NewMessageParser(identity.UserID, o.triggers),
+		userID:         identity.UserID,
+		user:           "@" + identity.UserName,
+		adminChannelID: o.adminChannelID,
+		workspace:      o.workspace,
+		channels:       newDirectory(directoryTTL),
+		users:          newDirectory(directoryTTL),
+		received:       newReceived(o.editWindow),
+		outbox:         newOutbox(transport, logger, o.sendInterval, o.sendBurst),
+		languages:      newLanguages(o.language),
+		done:           make(chan struct{}),
 	}, nil
 }
 
@@ -71,93 +186,314 @@ func (bot *bot) RegisterHandler(handler Handler) error {
 	return nil
 }
 
+// Use adds middlewares wrapping every handler, the first one is the outermost.
+func (bot *bot) Use(middlewares ...Middleware) {
+	bot.middlewares = append(bot.middlewares, middlewares...)
+}
+
+// Stop stops receiving messages and makes Start return, the handlers in flight keep running.
 func (bot *bot) Stop() {
+	bot.mutex.Lock()
+	if bot.stopped {
+		bot.mutex.Unlock()
+		return
+	}
 	bot.stopped = true
-	bot.logger.Print("bot stopped")
+	close(bot.done)
+	bot.mutex.Unlock()
+
+	if err := bot.transport.Disconnect(); err != nil {
+		bot.logger.Error("fail to disconnect", "err", err)
+	}
+	bot.logger.Info("bot stopped")
+}
+
+// Shutdown stops the bot and waits for the handlers in flight and the queued messages until ctx is done.
+func (bot *bot) Shutdown(ctx context.Context) error {
+	bot.Stop()
+	drained := make(chan struct{})
+	go func() {
+		bot.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if !bot.outbox.wait(ctx.Done()) {
+		return ctx.Err()
+	}
+	return nil
 }
 
+// Start receives events until the bot is stopped or the transport closes its events.
 func (bot *bot) Start() {
-	go bot.rtm.ManageConnection()
-	bot.logger.Print("start receiving incoming events...")
-	for ev := range bot.rtm.IncomingEvents {
-		if bot.stopped {
-			break
+	go bot.warmUp()
+	go bot.transport.Run()
+	bot.logger.Info("start receiving incoming events...")
+	events := bot.transport.IncomingEvents()
+	for {
+		select {
+		case <-bot.done:
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			bot.onEvent(ev)
 		}
+	}
+}
 
-		if msg, ok := ev.Data.(*slack.MessageEvent); ok {
-			bot.onMessage(msg)
+// spawn runs f in a goroutine Shutdown waits for, f is dropped once the bot is stopped.
+func (bot *bot) spawn(f func()) {
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	if bot.stopped {
+		return
+	}
+	bot.inflight.Add(1)
+	bot.running++
+	go func() {
+		defer bot.inflight.Done()
+		defer func() {
+			bot.mutex.Lock()
+			bot.running--
+			bot.mutex.Unlock()
+		}()
+		f()
+	}()
+}
+
+func (bot *bot) onEvent(ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			bot.ReportPanic(fmt.Sprintf("%s event: %+v", ev.Type, ev.Data), r, debug.Stack())
+		}
+	}()
+
+	bot.health.received(ev)
+
+	switch data := ev.Data.(type) {
+	case *MessageEvent:
+		bot.onMessage(data)
+		return
+	case *ChannelRenameEvent:
+		bot.channels.set(data.ChannelID, channelName(Channel{ID: data.ChannelID, Name: data.Name}))
+	case *ChannelCreatedEvent:
+		bot.channels.set(data.ChannelID, channelName(Channel{ID: data.ChannelID, Name: data.Name}))
+	case *UserChangeEvent:
+		bot.users.set(data.User.ID, userName(data.User))
+	case *IMCreatedEvent:
+		bot.channels.set(data.ChannelID, directMessageName)
+	case *ConnectedEvent:
+		bot.connected++
+		if bot.connected > 1 {
+			reconnects.Inc()
 		}
+	case *ErrorEvent:
+		bot.logger.Error("transport error", "err", data.Err)
+	}
+	bot.dispatchEvent(ev)
+}
+
+// warmUp fills the channel directory with the conversations the bot can see.
+func (bot *bot) warmUp() {
+	channels, err := bot.transport.ListChannels()
+	if err != nil {
+		bot.logger.Warn("fail to list conversations", "err", err)
+		return
 	}
+	for _, c := range channels {
+		bot.channels.set(c.ID, channelName(c))
+	}
+	bot.logger.Info("conversations loaded", "count", len(channels))
 }
 
-func (bot *bot) onMessage(msg *slack.MessageEvent) {
+func (bot *bot) onMessage(msg *MessageEvent) {
 	// ignore bot message
 	if len(msg.BotID) > 0 {
 		return
 	}
+	ref := MessageRef{ChannelID: msg.ChannelID, TS: msg.TS}
+	if msg.Edited {
+		// edits of old messages and updates leaving the text as is, e.g. unfurled links, are ignored
+		if msg.Text == msg.PreviousText || !bot.received.within(ref) {
+			return
+		}
+	} else if len(msg.TS) > 0 {
+		bot.received.add(ref)
+	}
 
-	if _, err := bot.LoadChannel(msg.Channel); err != nil {
-		bot.logger.Print(err)
+	if _, err := bot.LoadChannel(msg.ChannelID); err != nil {
+		bot.logger.Error("fail to load channel", "channel", msg.ChannelID, "err", err)
 		return
 	}
-	if _, err := bot.LoadUser(msg.User); err != nil {
-		bot.logger.Print(err)
+	if _, err := bot.LoadUser(msg.UserID); err != nil {
+		bot.logger.Error("fail to load user", "user", msg.UserID, "err", err)
 		return
 	}
 
-	parsedMsg := bot.msgParser.Parse(msg.Text, msg.Channel, msg.User)
+	parsedMsg := bot.msgParser.Parse(msg.Text, msg.ChannelID, msg.UserID)
+	parsedMsg.TS = msg.TS
+	parsedMsg.ThreadTS = msg.ThreadTS
+	parsedMsg.Edited = msg.Edited
+	parsedMsg.Action = msg.Action
+	parsedMsg.Workspace = bot.workspace
+	parsedMsg.Lang = bot.Language(msg.UserID)
+	if msg.Mention && parsedMsg.Type == ListenTo {
+		parsedMsg.Type = ReplyTo
+	}
+	parsedMsg.CorrelationID = msg.TS
+	if len(parsedMsg.CorrelationID) == 0 {
+		// slash commands and button clicks have no timestamp
+		parsedMsg.CorrelationID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	messagesReceived.Inc(parsedMsg.Type.String())
+	bot.logger.With(parsedMsg.LogFields()...).Debug("message received", "type", parsedMsg.Type, "text", parsedMsg.Text, "edited", msg.Edited)
+
+	// answers to questions asked by handlers
+	if bot.conversations.deliver(parsedMsg) {
+		return
+	}
 
 	var handled bool
 	for _, handler := range bot.handlers {
-		if handler.NeedsMention && parsedMsg.Type == ListenTo {
+		if !handler.Accepts(parsedMsg) {
 			continue
 		}
 		if !handler.Handleable(bot, parsedMsg) {
 			continue
 		}
-		go bot.handle(handler, parsedMsg)
+		handler := handler
+		bot.spawn(func() { bot.handle(handler, parsedMsg) })
 		// handle message only once
 		handled = true
 		break
 	}
 
+	// posted without waiting, the event loop must not wait behind the queue of a busy channel
 	if !handled && parsedMsg.Type != ListenTo {
-		bot.SendMessage(ai.Answer(msg.Text), msg.Channel)
+		bot.Post(OutgoingMessage{ChannelID: msg.ChannelID, Text: ai.Answer(msg.Text)})
 	}
 }
 
 func (bot *bot) handle(handler Handler, msg Message) {
-	if err := handler.Handle(bot, msg); err != nil {
-		bot.SendMessage(fmt.Sprintf("<@%s> *failed* - `%s` :see_no_evil: (error: %s)", msg.UserID, msg.Text, err), msg.ChannelID)
+	msg.Handler = handler.Name
+	defer func() {
+		if r := recover(); r != nil {
+			bot.ReportPanic(fmt.Sprintf("%s: `%s` by <@%s>", handler.Name, msg.Text, msg.UserID), r, debug.Stack())
+			bot.SendMessage(msg.T("failed.panic", msg.UserID, msg.Text), msg.ChannelID)
+		}
+	}()
+	if err := chain(handler.Handle, bot.middlewares)(bot, msg); err != nil {
+		bot.Send(OutgoingMessage{
+			ChannelID: msg.ChannelID,
+			Text:      msg.T("failed.error", msg.UserID, msg.Text, msg.TErr(err)),
+			Rich: &RichMessage{
+				Color: ColorDanger,
+				Sections: []Section{{
+					Text:   msg.T("failed", msg.UserID, msg.Text),
+					Fields: []Field{{Title: msg.T("field.error"), Value: msg.TErr(err)}},
+				}},
+			},
+		})
 	}
 }
 
-func (bot *bot) GetRTM() *slack.RTM {
-	return bot.rtm
+func (bot *bot) GetTransport() Transport {
+	return bot.transport
 }
 
-func (bot *bot) GetLogger() *log.Logger {
+func (bot *bot) GetLogger() Logger {
 	return bot.logger
 }
 
-func (bot *bot) SendMessage(text string, channelID string) {
-	bot.rtm.SendMessage(bot.rtm.NewOutgoingMessage(text, channelID))
+func (bot *bot) Health() Health {
+	h := bot.health.get()
+	bot.mutex.Lock()
+	h.Handlers = bot.running
+	bot.mutex.Unlock()
+	h.Questions = bot.conversations.asked()
+	h.Queued = bot.outbox.queued()
+	return h
+}
+
+func (bot *bot) SendMessage(text string, channelID string) (MessageRef, error) {
+	return bot.Send(OutgoingMessage{ChannelID: channelID, Text: text})
+}
+
+// ReplyMessage posts text into the thread of msg.
+func (bot *bot) ReplyMessage(text string, msg Message) (MessageRef, error) {
+	return bot.Send(OutgoingMessage{ChannelID: msg.ChannelID, ThreadTS: msg.Thread(), Text: text})
+}
+
+// Send sends msg in order with the other messages of the channel, and waits for it,
+// long texts are split into several messages or uploaded as a file.
+func (bot *bot) Send(msg OutgoingMessage) (MessageRef, error) {
+	ref, err := bot.outbox.send(msg)
+	if err != nil {
+		bot.logger.Error("fail to send message", "channel", msg.ChannelID, "err", err)
+	}
+	return ref, err
+}
+
+// Post sends msg like Send without waiting for it, e.g. to forward the output of a command as it comes.
+// Messages posted to the same place in a row may be merged into one.
+func (bot *bot) Post(msg OutgoingMessage) {
+	bot.outbox.post(msg)
+}
+
+// UpdateMessage replaces the message of ref with msg, the channel and thread of msg are ignored.
+func (bot *bot) UpdateMessage(ref MessageRef, msg OutgoingMessage) error {
+	if msg.Rich != nil && len(msg.Text) == 0 {
+		msg.Text = msg.Rich.PlainText()
+	}
+	err := bot.transport.UpdateMessage(ref, msg)
+	if err != nil {
+		bot.logger.Error("fail to update message", "channel", ref.ChannelID, "ts", ref.TS, "err", err)
+	}
+	return err
+}
+
+func (bot *bot) DeleteMessage(ref MessageRef) error {
+	err := bot.transport.DeleteMessage(ref)
+	if err != nil {
+		bot.logger.Error("fail to delete message", "channel", ref.ChannelID, "ts", ref.TS, "err", err)
+	}
+	return err
+}
+
+// AddReaction reacts to the message of ref with the emoji name, e.g. "eyes".
+func (bot *bot) AddReaction(name string, ref MessageRef) error {
+	err := bot.transport.AddReaction(name, ref)
+	if err != nil {
+		bot.logger.Error("fail to add reaction", "reaction", name, "channel", ref.ChannelID, "ts", ref.TS, "err", err)
+	}
+	return err
+}
+
+func (bot *bot) RemoveReaction(name string, ref MessageRef) error {
+	err := bot.transport.RemoveReaction(name, ref)
+	if err != nil {
+		bot.logger.Error("fail to remove reaction", "reaction", name, "channel", ref.ChannelID, "ts", ref.TS, "err", err)
+	}
+	return err
 }
 
 func (bot *bot) LoadChannel(channelID string) (string, error) {
-	if c, ok := bot.channels[channelID]; ok {
-		return c, nil
+	if name, ok := bot.channels.get(channelID); ok {
+		return name, nil
 	}
 
-	c, err := bot.rtm.GetConversationInfo(channelID, false)
+	c, err := bot.transport.GetChannel(channelID)
 	if err != nil {
 		return "", fmt.Errorf("fail to get connversation(%s): %v", channelID, err)
 	}
-	bot.channels[channelID] = "#" + c.Name
-	if c.IsIM {
-		bot.channels[channelID] = "<direct message>"
-	}
-	return bot.channels[channelID], nil
+	name := channelName(*c)
+	bot.channels.set(channelID, name)
+	return name, nil
 }
 
 func (bot *bot) LoadUser(userID string) (string, error) {
@@ -166,20 +502,61 @@ func (bot *bot) LoadUser(userID string) (string, error) {
 		return "", nil
 	}
 
-	if u, ok := bot.users[userID]; ok {
-		return u, nil
+	if name, ok := bot.users.get(userID); ok {
+		return name, nil
 	}
 
-	user, err := bot.rtm.GetUserInfo(userID)
+	user, err := bot.transport.GetUser(userID)
 	if err != nil {
 		return "", fmt.Errorf("fail to get user(%s): %v", userID, err)
 	}
-	bot.users[userID] = "@" + user.Profile.DisplayName
-	return bot.users[userID], nil
+	name := userName(*user)
+	bot.users.set(userID, name)
+	return name, nil
+}
+
+// SetUserLanguage selects the language of replies to the user, an empty lang resets it to the one of the workspace.
+func (bot *bot) SetUserLanguage(userID, lang string) {
+	bot.languages.setUser(userID, lang)
+}
+
+// Language returns the language of replies to the user.
+func (bot *bot) Language(userID string) string {
+	return bot.languages.of(userID)
+}
+
+// Workspace returns the name of the workspace, it's empty unless given by OptionWorkspace.
+func (bot *bot) Workspace() string {
+	return bot.workspace
+}
+
+// ReportPanic logs a recovered panic r that happened in where, and reports it to the admin channel if there is one,
+// without waiting as it's called from the event loop too.
+func (bot *bot) ReportPanic(where string, r interface{}, stack []byte) {
+	bot.logger.Error("panic", "where", where, "panic", fmt.Sprint(r), "stack", string(stack))
+	if len(bot.adminChannelID) == 0 {
+		return
+	}
+	trace := string(stack)
+	if len(trace) > maxStackLength {
+		trace = trace[:maxStackLength] + "\n..."
+	}
+	bot.Post(OutgoingMessage{
+		ChannelID: bot.adminChannelID,
+		Rich: &RichMessage{
+			Color: ColorDanger,
+			Sections: []Section{{
+				Title:   fmt.Sprintf("panic: %v", r),
+				Text:    "```\n" + trace + "\n```",
+				Context: where,
+			}},
+		},
+	})
 }
 
-func (bot *bot) Help() string {
-	h := []string{"```", "available commands:"}
+// Help lists the commands in lang, the usages of the handlers are not translated.
+func (bot *bot) Help(lang string) string {
+	h := []string{"```", Translate(lang, "help.title")}
 	for _, handler := range bot.handlers {
 		s := "  * "
 		if handler.NeedsMention {
@@ -0,0 +1,235 @@
+package gobot
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeTransport struct {
+	events chan Event
+	sent   chan string
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{events: make(chan Event), sent: make(chan string, 10)}
+}
+
+func (t *fakeTransport) Connect() (*Identity, error) {
+	return &Identity{UserID: "UBOT", UserName: "gobot"}, nil
+}
+
+func (t *fakeTransport) Run() {}
+
+func (t *fakeTransport) Disconnect() error {
+	return nil
+}
+
+func (t *fakeTransport) IncomingEvents() <-chan Event {
+	return t.events
+}
+
+func (t *fakeTransport) SendMessage(msg OutgoingMessage) (MessageRef, error) {
+	t.sent <- msg.ChannelID + ": " + msg.Text
+	return MessageRef{ChannelID: msg.ChannelID, TS: "1.1"}, nil
+}
+
+func (t *fakeTransport) UploadFile(file FileUpload) (MessageRef, error) {
+	t.sent <- file.ChannelID + ": " + file.Filename + "\n" + file.Content
+	return MessageRef{ChannelID: file.ChannelID}, nil
+}
+
+func (t *fakeTransport) UpdateMessage(ref MessageRef, msg OutgoingMessage) error {
+	return nil
+}
+
+func (t *fakeTransport) DeleteMessage(ref MessageRef) error {
+	return nil
+}
+
+func (t *fakeTransport) AddReaction(name string, ref MessageRef) error {
+	return nil
+}
+
+func (t *fakeTransport) RemoveReaction(name string, ref MessageRef) error {
+	return nil
+}
+
+func (t *fakeTransport) GetUser(userID string) (*User, error) {
+	return &User{ID: userID, Name: "name", DisplayName: "display"}, nil
+}
+
+func (t *fakeTransport) GetChannel(channelID string) (*Channel, error) {
+	return &Channel{ID: channelID, Name: "general"}, nil
+}
+
+func (t *fakeTransport) ListChannels() ([]Channel, error) {
+	return []Channel{{ID: "C123", Name: "general"}, {ID: "D123", IsIM: true}}, nil
+}
+
+func TestBot_Start(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.RegisterHandler(Handler{
+		Name:         "ping",
+		Help:         "ping",
+		NeedsMention: true,
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "ping"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			bot.SendMessage("pong", msg.ChannelID)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go b.Start()
+
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C123", UserID: "U123", Text: "<@UBOT> ping"}}
+	select {
+	case got := <-transport.sent:
+		if want := "C123: pong"; got != want {
+			t.Errorf("sent = %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Error("no message sent")
+	}
+}
+
+func TestBot_handle_panic(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt), OptionAdminChannel("CADMIN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := Handler{
+		Name: "gacha",
+		Help: "gacha",
+		Handleable: func(bot Bot, msg Message) bool {
+			return true
+		},
+		Handle: func(bot Bot, msg Message) error {
+			var restaurants []string
+			bot.SendMessage(restaurants[0], msg.ChannelID)
+			return nil
+		},
+	}
+	b.(*bot).handle(handler, Message{Text: "gacha", ChannelID: "C123", UserID: "U123"})
+	// the panic is posted to the admin channel without waiting
+	b.(*bot).outbox.wait(nil)
+
+	var sent []string
+	for len(transport.sent) > 0 {
+		sent = append(sent, <-transport.sent)
+	}
+	sort.Strings(sent)
+	want := []string{"C123: <@U123> *failed* - `gacha`", "CADMIN: *panic: runtime error"}
+	if len(sent) != len(want) {
+		t.Fatalf("sent = %q, want prefixes %q", sent, want)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(sent[i], w) {
+			t.Errorf("sent = %v, want prefix %v", sent[i], w)
+		}
+	}
+}
+
+func TestBot_onMessage_action(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.RegisterHandler(Handler{
+		Name:         "kill",
+		Help:         "kill",
+		NeedsMention: true,
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "kill 12"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			bot.SendMessage("killed by "+msg.Action.ID, msg.ChannelID)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.(*bot).onMessage(&MessageEvent{ChannelID: "C123", UserID: "U123", Text: "kill 12", Mention: true, Action: &Action{ID: "button"}})
+	select {
+	case got := <-transport.sent:
+		if want := "C123: killed by button"; got != want {
+			t.Errorf("sent = %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Error("no message sent")
+	}
+}
+
+func TestBot_Shutdown(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handling, release := make(chan struct{}), make(chan struct{})
+	err = b.RegisterHandler(Handler{
+		Name: "slow",
+		Help: "slow",
+		Handleable: func(bot Bot, msg Message) bool {
+			return true
+		},
+		Handle: func(bot Bot, msg Message) error {
+			close(handling)
+			<-release
+			bot.SendMessage("done", msg.ChannelID)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	go func() {
+		b.Start()
+		close(started)
+	}()
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C123", UserID: "U123", Text: "slow"}}
+	<-handling
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := b.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("Start() didn't return")
+	}
+
+	// messages are no longer handled
+	b.(*bot).onMessage(&MessageEvent{ChannelID: "C456", UserID: "U123", Text: "slow"})
+
+	close(release)
+	if err := b.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() = %v, want nil", err)
+	}
+	if got, want := <-transport.sent, "C123: done"; got != want {
+		t.Errorf("sent = %v, want %v", got, want)
+	}
+	select {
+	case got := <-transport.sent:
+		t.Errorf("sent = %v after stopped", got)
+	default:
+	}
+}
@@ -0,0 +1,116 @@
+package gobot
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// waiter waits for the next message of a user in a channel or thread.
+type waiter struct {
+	userID    string
+	channelID string
+	threadTS  string
+	ch        chan Message
+	// asked is set once the question is sent
+	asked bool
+}
+
+func (w *waiter) matches(msg Message) bool {
+	return w.userID == msg.UserID && w.channelID == msg.ChannelID && w.threadTS == msg.ThreadTS
+}
+
+type conversations struct {
+	mutex   sync.Mutex
+	waiters []*waiter
+}
+
+func (c *conversations) add(w *waiter) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.waiters = append(c.waiters, w)
+}
+
+func (c *conversations) remove(w *waiter) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, ww := range c.waiters {
+		if ww == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// asked returns how many questions are sent and waiting for an answer.
+func (c *conversations) asked() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var n int
+	for _, w := range c.waiters {
+		if w.asked {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *conversations) setAsked(w *waiter) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w.asked = true
+}
+
+// deliver passes msg to the waiter waiting for it, it returns false if nobody waits.
+func (c *conversations) deliver(msg Message) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, w := range c.waiters {
+		if !w.matches(msg) {
+			continue
+		}
+		c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+		w.ch <- msg
+		return true
+	}
+	return false
+}
+
+// Ask posts question where msg was posted and waits for the next message of the same user there,
+// the answer isn't dispatched to handlers. It gives up when ctx is done or the bot is stopped.
+func (bot *bot) Ask(ctx context.Context, msg Message, question string) (Message, error) {
+	w := &waiter{userID: msg.UserID, channelID: msg.ChannelID, threadTS: msg.ThreadTS, ch: make(chan Message, 1)}
+	bot.conversations.add(w)
+
+	out := OutgoingMessage{ChannelID: msg.ChannelID, ThreadTS: msg.ThreadTS, Text: "<@" + msg.UserID + "> " + question}
+	if _, err := bot.Send(out); err != nil {
+		bot.conversations.remove(w)
+		return Message{}, err
+	}
+	bot.conversations.setAsked(w)
+
+	select {
+	case answer := <-w.ch:
+		return answer, nil
+	case <-ctx.Done():
+		bot.conversations.remove(w)
+		return Message{}, ctx.Err()
+	case <-bot.done:
+		bot.conversations.remove(w)
+		return Message{}, ErrStopped
+	}
+}
+
+// Confirm asks a yes/no question and reports whether the user answered yes.
+func Confirm(ctx context.Context, bot Bot, msg Message, question string) (bool, error) {
+	answer, err := bot.Ask(ctx, msg, question+" "+msg.T("confirm.choices"))
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer.Text) {
+	case "y", "yes", "はい":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
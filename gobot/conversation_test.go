@@ -0,0 +1,60 @@
+package gobot
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBot_Ask(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.RegisterHandler(Handler{
+		Name:         "deploy",
+		Help:         "deploy",
+		NeedsMention: true,
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "deploy"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			ok, err := Confirm(ctx, bot, msg, "really?")
+			if err != nil {
+				return err
+			}
+			if ok {
+				bot.SendMessage("deployed", msg.ChannelID)
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go b.Start()
+
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C123", UserID: "U123", Text: "<@UBOT> deploy"}}
+	expectSent(t, transport, "C123: <@U123> really? (yes/no)")
+	// other users and channels don't answer
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C123", UserID: "U456", Text: "yes"}}
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C456", UserID: "U123", Text: "yes"}}
+	transport.events <- Event{Type: "message", Data: &MessageEvent{ChannelID: "C123", UserID: "U123", Text: "yes"}}
+	expectSent(t, transport, "C123: deployed")
+}
+
+func expectSent(t *testing.T, transport *fakeTransport, want string) {
+	t.Helper()
+	select {
+	case got := <-transport.sent:
+		if got != want {
+			t.Errorf("sent = %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no message sent, want %v", want)
+	}
+}
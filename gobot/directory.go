@@ -0,0 +1,55 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	directoryTTL      = time.Hour
+	directMessageName = "<direct message>"
+)
+
+type directoryEntry struct {
+	name     string
+	expireAt time.Time
+}
+
+// directory caches the names of channels or users by ID, entries expire after ttl
+// so that renames are picked up even when their events are missed.
+type directory struct {
+	mutex   sync.RWMutex
+	ttl     time.Duration
+	entries map[string]directoryEntry
+}
+
+func newDirectory(ttl time.Duration) *directory {
+	return &directory{ttl: ttl, entries: make(map[string]directoryEntry)}
+}
+
+func (d *directory) get(id string) (string, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	e, ok := d.entries[id]
+	if !ok || time.Now().After(e.expireAt) {
+		return "", false
+	}
+	return e.name, true
+}
+
+func (d *directory) set(id, name string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.entries[id] = directoryEntry{name: name, expireAt: time.Now().Add(d.ttl)}
+}
+
+func channelName(c Channel) string {
+	if c.IsIM {
+		return directMessageName
+	}
+	return "#" + c.Name
+}
+
+func userName(u User) string {
+	return "@" + u.DisplayName
+}
@@ -0,0 +1,78 @@
+package gobot
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestDirectory_get(t *testing.T) {
+	d := newDirectory(time.Millisecond)
+	d.set("C123", "#general")
+	if name, ok := d.get("C123"); !ok || name != "#general" {
+		t.Errorf("get() = %v, %v, want #general, true", name, ok)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if name, ok := d.get("C123"); ok {
+		t.Errorf("get() = %v, %v, want expired", name, ok)
+	}
+}
+
+func TestBot_onEvent_directory(t *testing.T) {
+	b, err := New(newFakeTransport(), NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bot := b.(*bot)
+	bot.warmUp()
+
+	tests := []struct {
+		name        string
+		event       Event
+		loadChannel string
+		loadUser    string
+		want        string
+	}{
+		{
+			name:        "warmed up",
+			loadChannel: "D123",
+			want:        "<direct message>",
+		},
+		{
+			name:        "channel rename",
+			event:       Event{Type: "channel_rename", Data: &ChannelRenameEvent{ChannelID: "C123", Name: "random"}},
+			loadChannel: "C123",
+			want:        "#random",
+		},
+		{
+			name:     "user change",
+			event:    Event{Type: "user_change", Data: &UserChangeEvent{User: User{ID: "U123", DisplayName: "taro"}}},
+			loadUser: "U123",
+			want:     "@taro",
+		},
+		{
+			name:        "im created",
+			event:       Event{Type: "im_created", Data: &IMCreatedEvent{ChannelID: "D456", UserID: "U123"}},
+			loadChannel: "D456",
+			want:        "<direct message>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot.onEvent(tt.event)
+			var got string
+			var err error
+			if len(tt.loadChannel) > 0 {
+				got, err = bot.LoadChannel(tt.loadChannel)
+			} else {
+				got, err = bot.LoadUser(tt.loadUser)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// received remembers when messages were received, so that only the ones edited within the window are dispatched again.
+type received struct {
+	mutex  sync.Mutex
+	window time.Duration
+	at     map[MessageRef]time.Time
+	pruned time.Time
+}
+
+func newReceived(window time.Duration) *received {
+	return &received{window: window, at: make(map[MessageRef]time.Time)}
+}
+
+func (r *received) add(ref MessageRef) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.window <= 0 {
+		return
+	}
+	now := time.Now()
+	r.at[ref] = now
+	// forget the messages out of the window from time to time
+	if now.Sub(r.pruned) < r.window {
+		return
+	}
+	for ref, at := range r.at {
+		if now.Sub(at) > r.window {
+			delete(r.at, ref)
+		}
+	}
+	r.pruned = now
+}
+
+// within tells whether the message was received within the window.
+func (r *received) within(ref MessageRef) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	at, ok := r.at[ref]
+	return ok && time.Since(at) <= r.window
+}
@@ -0,0 +1,68 @@
+package gobot
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBot_onMessage_edited(t *testing.T) {
+	b := newTestBot(t, OptionEditWindow(time.Minute)).(*bot)
+	var mutex sync.Mutex
+	var handled []string
+	err := b.RegisterHandler(Handler{
+		Name: "dist-beta",
+		Help: "dist-beta",
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "dist-beta --branch foo"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			handled = append(handled, msg.TS)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []*MessageEvent{
+		{ChannelID: "C123", UserID: "U123", Text: "dist-beta --brnch foo", TS: "1.1"},
+		// fixed typo
+		{ChannelID: "C123", UserID: "U123", Text: "dist-beta --branch foo", TS: "1.1", Edited: true, PreviousText: "dist-beta --brnch foo"},
+		// unfurled link leaving the text as is
+		{ChannelID: "C123", UserID: "U123", Text: "dist-beta --branch foo", TS: "1.1", Edited: true, PreviousText: "dist-beta --branch foo"},
+		// message received before the bot started
+		{ChannelID: "C123", UserID: "U123", Text: "dist-beta --branch foo", TS: "0.1", Edited: true, PreviousText: "dist"},
+	}
+	for _, ev := range events {
+		b.onMessage(ev)
+	}
+	b.inflight.Wait()
+	if want := []string{"1.1"}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want %v", handled, want)
+	}
+}
+
+func TestReceived_within(t *testing.T) {
+	ref := MessageRef{ChannelID: "C123", TS: "1.1"}
+	tests := []struct {
+		window time.Duration
+		sleep  time.Duration
+		want   bool
+	}{
+		{window: time.Minute, want: true},
+		{window: 10 * time.Millisecond, sleep: 20 * time.Millisecond, want: false},
+		{window: 0, want: false},
+	}
+	for _, tt := range tests {
+		r := newReceived(tt.window)
+		r.add(ref)
+		time.Sleep(tt.sleep)
+		if got := r.within(ref); got != tt.want {
+			t.Errorf("window %v: within() = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package gobot
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	entity    = regexp.MustCompile(`<([^<>]*)>`)
+	unescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&")
+)
+
+// Entity is a user, a channel or a user group referred to in a message, Name is empty unless slack gives one.
+type Entity struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Link is a link in a message, Label is empty unless slack gives one, e.g. the text a URL was typed as.
+type Link struct {
+	URL   string `json:"url"`
+	Label string `json:"label,omitempty"`
+}
+
+// decodeEntities replaces the entities slack wraps in <> by their plain text, e.g. <#C123|general> by #general,
+// and collects them into msg. The HTML escapes slack adds are unescaped.
+func decodeEntities(msg *Message) {
+	text := msg.Text
+	var b strings.Builder
+	last := 0
+	for _, m := range entity.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(unescaper.Replace(text[last:m[0]]))
+		b.WriteString(msg.addEntity(text[m[2]:m[3]]))
+		last = m[1]
+	}
+	b.WriteString(unescaper.Replace(text[last:]))
+	msg.Text = b.String()
+}
+
+// addEntity adds the entity s, what's inside <>, to msg and returns its plain text.
+func (msg *Message) addEntity(s string) string {
+	var label string
+	if i := strings.Index(s, "|"); i >= 0 {
+		s, label = s[:i], unescaper.Replace(s[i+1:])
+	}
+	s = unescaper.Replace(s)
+	switch {
+	case strings.HasPrefix(s, "@"):
+		msg.Users = append(msg.Users, Entity{ID: s[1:], Name: label})
+		return "@" + orElse(label, s[1:])
+	case strings.HasPrefix(s, "#"):
+		msg.Channels = append(msg.Channels, Entity{ID: s[1:], Name: label})
+		return "#" + orElse(label, s[1:])
+	case strings.HasPrefix(s, "!subteam^"):
+		id := s[len("!subteam^"):]
+		name := strings.TrimPrefix(label, "@")
+		msg.Groups = append(msg.Groups, Entity{ID: id, Name: name})
+		return "@" + orElse(name, id)
+	case strings.HasPrefix(s, "!"):
+		// special mentions like @here, or dates with their fallback text
+		return orElse(label, "@"+s[1:])
+	default:
+		msg.Links = append(msg.Links, Link{URL: s, Label: label})
+		return orElse(label, s)
+	}
+}
+
+func orElse(s, fallback string) string {
+	if len(s) > 0 {
+		return s
+	}
+	return fallback
+}
@@ -0,0 +1,56 @@
+package gobot
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// EventHandler handles the events other than messages, e.g. reactions or members joining channels.
+// Unlike message handlers, every handleable event handler gets the event.
+type EventHandler struct {
+	Name       string
+	Handleable func(bot Bot, ev Event) bool
+	Handle     func(bot Bot, ev Event) error
+}
+
+func (h EventHandler) IsValid() bool {
+	return len(h.Name) > 0 && h.Handleable != nil && h.Handle != nil
+}
+
+func (bot *bot) RegisterEventHandler(handler EventHandler) error {
+	if !handler.IsValid() {
+		return ErrInvalidHandler
+	}
+	for _, h := range bot.eventHandlers {
+		if h.Name == handler.Name {
+			return ErrDuplicateRegister
+		}
+	}
+	bot.eventHandlers = append(bot.eventHandlers, handler)
+	return nil
+}
+
+func (bot *bot) dispatchEvent(ev Event) {
+	// ignore reactions of the bot itself, e.g. the ones acknowledging tasks
+	if data, ok := ev.Data.(*ReactionEvent); ok && data.UserID == bot.userID {
+		return
+	}
+	for _, handler := range bot.eventHandlers {
+		if !handler.Handleable(bot, ev) {
+			continue
+		}
+		handler := handler
+		bot.spawn(func() { bot.handleEvent(handler, ev) })
+	}
+}
+
+func (bot *bot) handleEvent(handler EventHandler, ev Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			bot.ReportPanic(fmt.Sprintf("%s: %s event: %+v", handler.Name, ev.Type, ev.Data), r, debug.Stack())
+		}
+	}()
+	if err := handler.Handle(bot, ev); err != nil {
+		bot.logger.Error("fail to handle event", "handler", handler.Name, "event", ev.Type, "err", err)
+	}
+}
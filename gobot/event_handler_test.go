@@ -0,0 +1,82 @@
+package gobot
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBot_dispatchEvent(t *testing.T) {
+	transport := newFakeTransport()
+	b, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.RegisterEventHandler(EventHandler{
+		Name: "greet",
+		Handleable: func(bot Bot, ev Event) bool {
+			return ev.Type == EventMemberJoinedChannel
+		},
+		Handle: func(bot Bot, ev Event) error {
+			data := ev.Data.(*MemberEvent)
+			_, err := bot.SendMessage("welcome <@"+data.UserID+">", data.ChannelID)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.RegisterEventHandler(EventHandler{
+		Name: "thanks",
+		Handleable: func(bot Bot, ev Event) bool {
+			return ev.Type == EventReactionAdded
+		},
+		Handle: func(bot Bot, ev Event) error {
+			data := ev.Data.(*ReactionEvent)
+			_, err := bot.SendMessage("thanks for :"+data.Reaction+":", data.Item.ChannelID)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RegisterEventHandler(EventHandler{Name: "greet"}); err != ErrInvalidHandler {
+		t.Errorf("RegisterEventHandler() = %v, want %v", err, ErrInvalidHandler)
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{
+			name:  "member joined",
+			event: Event{Type: EventMemberJoinedChannel, Data: &MemberEvent{ChannelID: "C123", UserID: "U123"}},
+			want:  "C123: welcome <@U123>",
+		},
+		{
+			name:  "reaction of the bot",
+			event: Event{Type: EventReactionAdded, Data: &ReactionEvent{UserID: "UBOT", Reaction: "eyes", Item: MessageRef{ChannelID: "C123"}}},
+		},
+		{
+			name:  "reaction",
+			event: Event{Type: EventReactionAdded, Data: &ReactionEvent{UserID: "U123", Reaction: "tada", Item: MessageRef{ChannelID: "C123"}}},
+			want:  "C123: thanks for :tada:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.(*bot).onEvent(tt.event)
+			select {
+			case got := <-transport.sent:
+				if got != tt.want {
+					t.Errorf("sent = %v, want %v", got, tt.want)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if len(tt.want) > 0 {
+					t.Errorf("no message sent, want %v", tt.want)
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,11 @@ type Handler struct {
 	Handle       func(bot Bot, msg Message) error
 }
 
+// Accepts tells whether msg may be handled by the handler, the ones needing a mention only get messages addressed to the bot.
+func (h Handler) Accepts(msg Message) bool {
+	return !h.NeedsMention || msg.Type != ListenTo
+}
+
 func (h Handler) IsValid() bool {
 	return len(h.Name) > 0 && len(h.Help) > 0 && h.Handleable != nil && h.Handle != nil
 }
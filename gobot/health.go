@@ -0,0 +1,42 @@
+package gobot
+
+import (
+	"sync"
+	"time"
+)
+
+// Health is the state of the connection to slack, and of the work in progress.
+type Health struct {
+	Connected bool
+	// LastEventAt is when the last event was received, zero if none yet
+	LastEventAt time.Time
+	// Handlers is how many handlers are running, including the ones waiting for an answer
+	Handlers int
+	// Questions is how many questions asked by handlers are waiting for an answer
+	Questions int
+	// Queued is how many messages are waiting to be sent
+	Queued int
+}
+
+type health struct {
+	mutex sync.RWMutex
+	state Health
+}
+
+func (h *health) received(ev Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.state.LastEventAt = time.Now()
+	switch ev.Data.(type) {
+	case *ConnectedEvent:
+		h.state.Connected = true
+	case *DisconnectedEvent:
+		h.state.Connected = false
+	}
+}
+
+func (h *health) get() Health {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.state
+}
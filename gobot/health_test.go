@@ -0,0 +1,83 @@
+package gobot
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestBot_Health(t *testing.T) {
+	b := newTestBot(t).(*bot)
+	if h := b.Health(); h.Connected || !h.LastEventAt.IsZero() {
+		t.Errorf("Health() = %+v, want zero", h)
+	}
+
+	tests := []struct {
+		ev            Event
+		wantConnected bool
+	}{
+		{ev: Event{Type: "connected", Data: &ConnectedEvent{}}, wantConnected: true},
+		{ev: Event{Type: "reaction_added", Data: &ReactionEvent{}}, wantConnected: true},
+		{ev: Event{Type: "disconnected", Data: &DisconnectedEvent{}}, wantConnected: false},
+	}
+	for _, tt := range tests {
+		b.onEvent(tt.ev)
+		h := b.Health()
+		if h.Connected != tt.wantConnected {
+			t.Errorf("%s: Connected = %v, want %v", tt.ev.Type, h.Connected, tt.wantConnected)
+		}
+		if h.LastEventAt.IsZero() {
+			t.Errorf("%s: LastEventAt is zero", tt.ev.Type)
+		}
+	}
+}
+
+func TestBot_Health_work(t *testing.T) {
+	transport := newFakeTransport()
+	bb, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := bb.(*bot)
+	answered := make(chan struct{})
+	err = b.RegisterHandler(Handler{
+		Name: "deploy",
+		Help: "deploy",
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "deploy"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			_, err := bot.Ask(context.Background(), msg, "branch?")
+			close(answered)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b.onMessage(&MessageEvent{ChannelID: "C123", UserID: "U123", Text: "deploy", TS: "1.1"})
+	<-transport.sent
+	waitHealth(t, b, Health{Handlers: 1, Questions: 1})
+	b.onMessage(&MessageEvent{ChannelID: "C123", UserID: "U123", Text: "main", TS: "1.2"})
+	<-answered
+	b.inflight.Wait()
+	waitHealth(t, b, Health{})
+}
+
+// waitHealth fails the test if the work in progress of b doesn't settle to want in time.
+func waitHealth(t *testing.T, b *bot, want Health) {
+	deadline := time.Now().Add(time.Second)
+	for {
+		h := b.Health()
+		if h.Handlers == want.Handlers && h.Questions == want.Questions && h.Queued == want.Queued {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("Health() = %+v, want handlers %d, questions %d, queued %d", h, want.Handlers, want.Questions, want.Queued)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,146 @@
+package gobot
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	LangEnglish  = "en"
+	LangJapanese = "ja"
+
+	// DefaultLanguage is the language of replies unless the workspace or the user selects another
+	DefaultLanguage = LangEnglish
+)
+
+// Bundle holds the messages of a language by key, a message is a fmt format.
+type Bundle map[string]string
+
+var (
+	catalogMutex sync.RWMutex
+	catalog      = map[string]Bundle{
+		LangEnglish: {
+			"failed":       "<@%s> *failed* - `%s` :see_no_evil:",
+			"failed.error": "<@%s> *failed* - `%s` :see_no_evil: (error: %s)",
+			"failed.panic": "<@%s> *failed* - `%s` :exploding_head: (something went wrong)",
+			"field.error":  "Error",
+			"help.title":   "available commands:",
+
+			"confirm.choices": "(yes/no)",
+
+			"error.rate_limited":  "too many requests, try again later",
+			"error.no_permission": "no permission",
+		},
+		LangJapanese: {
+			"failed":       "<@%s> *失敗* - `%s` :see_no_evil:",
+			"failed.error": "<@%s> *失敗* - `%s` :see_no_evil: (エラー: %s)",
+			"failed.panic": "<@%s> *失敗* - `%s` :exploding_head: (問題が発生しました)",
+			"field.error":  "エラー",
+			"help.title":   "使えるコマンド:",
+
+			"confirm.choices": "(はい/いいえ)",
+
+			"error.rate_limited":  "リクエストが多すぎます、しばらくしてから試してください",
+			"error.no_permission": "権限がありません",
+		},
+	}
+)
+
+// RegisterMessages adds messages to the bundle of lang, a package replying to users registers its own in init.
+func RegisterMessages(lang string, messages Bundle) {
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+	bundle, ok := catalog[lang]
+	if !ok {
+		bundle = make(Bundle)
+		catalog[lang] = bundle
+	}
+	for key, message := range messages {
+		bundle[key] = message
+	}
+}
+
+// Languages returns the languages there is a bundle of.
+func Languages() []string {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+	var langs []string
+	for lang := range catalog {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// IsLanguage tells whether there is a bundle of lang.
+func IsLanguage(lang string) bool {
+	catalogMutex.RLock()
+	defer catalogMutex.RUnlock()
+	_, ok := catalog[lang]
+	return ok
+}
+
+// Translate formats the message of key in lang with args,
+// the message of DefaultLanguage is used if lang has none, and the key itself if neither has one.
+func Translate(lang, key string, args ...interface{}) string {
+	catalogMutex.RLock()
+	message, ok := catalog[lang][key]
+	if !ok {
+		message, ok = catalog[DefaultLanguage][key]
+	}
+	catalogMutex.RUnlock()
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Error is an error replied to users in their language by the message of Key formatted with Args,
+// Error() is the message in DefaultLanguage for logs.
+type Error struct {
+	Key  string
+	Args []interface{}
+}
+
+func NewError(key string, args ...interface{}) *Error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return Translate(DefaultLanguage, e.Key, e.Args...)
+}
+
+// languages holds the language of the workspace and the ones selected by users.
+type languages struct {
+	mutex     sync.RWMutex
+	workspace string
+	users     map[string]string
+}
+
+func newLanguages(workspace string) *languages {
+	return &languages{workspace: workspace, users: make(map[string]string)}
+}
+
+// setUser selects lang for the user, an empty lang falls back to the language of the workspace.
+func (l *languages) setUser(userID, lang string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(lang) == 0 {
+		delete(l.users, userID)
+		return
+	}
+	l.users[userID] = lang
+}
+
+func (l *languages) of(userID string) string {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if lang, ok := l.users[userID]; ok {
+		return lang
+	}
+	return l.workspace
+}
@@ -0,0 +1,98 @@
+package gobot
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	RegisterMessages(LangEnglish, Bundle{"test.greeting": "hello %s", "test.english": "english only"})
+	RegisterMessages(LangJapanese, Bundle{"test.greeting": "こんにちは %s"})
+
+	tests := []struct {
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{lang: LangEnglish, key: "test.greeting", args: []interface{}{"alice"}, want: "hello alice"},
+		{lang: LangJapanese, key: "test.greeting", args: []interface{}{"alice"}, want: "こんにちは alice"},
+		// falls back to the default language, then to the key
+		{lang: LangJapanese, key: "test.english", want: "english only"},
+		{lang: "fr", key: "test.greeting", args: []interface{}{"alice"}, want: "hello alice"},
+		{lang: LangEnglish, key: "test.missing", want: "test.missing"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("Translate(%q, %q, %v) = %q, want %q", tt.lang, tt.key, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestBot_Language(t *testing.T) {
+	transport := newFakeTransport()
+	bb, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt), OptionLanguage(LangJapanese))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := bb.(*bot)
+	b.SetUserLanguage("U456", LangEnglish)
+	err = b.RegisterHandler(Handler{
+		Name: "deploy",
+		Help: "deploy",
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "deploy"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			return errors.New("boom")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		{userID: "U123", want: "C123: <@U123> *失敗* - `deploy` :see_no_evil: (エラー: boom)"},
+		{userID: "U456", want: "C123: <@U456> *failed* - `deploy` :see_no_evil: (error: boom)"},
+	}
+	for _, tt := range tests {
+		b.onMessage(&MessageEvent{ChannelID: "C123", UserID: tt.userID, Text: "deploy", TS: "1.1"})
+		b.inflight.Wait()
+		if got := <-transport.sent; got != tt.want {
+			t.Errorf("sent = %q, want %q", got, tt.want)
+		}
+	}
+
+	b.SetUserLanguage("U456", "")
+	if got := b.Language("U456"); got != LangJapanese {
+		t.Errorf("Language(U456) = %v, want %v", got, LangJapanese)
+	}
+}
+
+func TestMessage_TErr(t *testing.T) {
+	RegisterMessages(LangEnglish, Bundle{"test.storage": "storage error: %v"})
+	RegisterMessages(LangJapanese, Bundle{"test.storage": "ストレージエラー: %v"})
+
+	tests := []struct {
+		lang string
+		err  error
+		want string
+	}{
+		{lang: LangJapanese, err: ErrNoPermission, want: "権限がありません"},
+		{lang: LangEnglish, err: ErrNoPermission, want: "no permission"},
+		{lang: LangJapanese, err: NewError("test.storage", errors.New("locked")), want: "ストレージエラー: locked"},
+		{lang: LangJapanese, err: errors.New("boom"), want: "boom"},
+	}
+	for _, tt := range tests {
+		if got := (Message{Lang: tt.lang}).TErr(tt.err); got != tt.want {
+			t.Errorf("TErr(%v) in %s = %q, want %q", tt.err, tt.lang, got, tt.want)
+		}
+	}
+	if got, want := NewError("test.storage", errors.New("locked")).Error(), "storage error: locked"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package gobot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:generate stringer -type=Level -linecomment
+type Level int
+
+const (
+	LevelDebug Level = iota // debug
+	LevelInfo               // info
+	LevelWarn               // warn
+	LevelError              // error
+)
+
+const (
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+
+	logTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Logger writes leveled structured logs, keyvals are alternating keys and values,
+// e.g. logger.Info("task finished", "task", 12, "duration", d).
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+	// With returns a logger adding keyvals to every line
+	With(keyvals ...interface{}) Logger
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error".
+func ParseLevel(s string) (Level, error) {
+	for l := LevelDebug; l <= LevelError; l++ {
+		if l.String() == strings.ToLower(s) {
+			return l, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %s", s)
+}
+
+type logger struct {
+	mutex   *sync.Mutex
+	w       io.Writer
+	level   Level
+	json    bool
+	keyvals []interface{}
+}
+
+// NewLogger returns a logger writing the lines of level or above to w in format, FormatLogfmt or FormatJSON.
+func NewLogger(w io.Writer, level Level, format string) Logger {
+	return &logger{mutex: &sync.Mutex{}, w: w, level: level, json: format == FormatJSON}
+}
+
+func (l *logger) Debug(msg string, keyvals ...interface{}) {
+	l.log(LevelDebug, msg, keyvals)
+}
+
+func (l *logger) Info(msg string, keyvals ...interface{}) {
+	l.log(LevelInfo, msg, keyvals)
+}
+
+func (l *logger) Warn(msg string, keyvals ...interface{}) {
+	l.log(LevelWarn, msg, keyvals)
+}
+
+func (l *logger) Error(msg string, keyvals ...interface{}) {
+	l.log(LevelError, msg, keyvals)
+}
+
+func (l *logger) With(keyvals ...interface{}) Logger {
+	with := *l
+	with.keyvals = append(append([]interface{}{}, l.keyvals...), keyvals...)
+	return &with
+}
+
+func (l *logger) log(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+	all := append([]interface{}{"time", time.Now().Format(logTimeFormat), "level", level.String(), "msg", msg}, l.keyvals...)
+	all = append(all, keyvals...)
+	if len(all)%2 != 0 {
+		all = append(all, "MISSING")
+	}
+
+	var buf bytes.Buffer
+	if l.json {
+		writeJSON(&buf, all)
+	} else {
+		writeLogfmt(&buf, all)
+	}
+	buf.WriteByte('\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = l.w.Write(buf.Bytes())
+}
+
+func writeLogfmt(buf *bytes.Buffer, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(fmt.Sprint(keyvals[i]))
+		buf.WriteByte('=')
+		v := fmt.Sprint(logValue(keyvals[i+1]))
+		if len(v) == 0 || strings.ContainsAny(v, " =\"\t\r\n") {
+			v = strconv.Quote(v)
+		}
+		buf.WriteString(v)
+	}
+}
+
+func writeJSON(buf *bytes.Buffer, keyvals []interface{}) {
+	buf.WriteByte('{')
+	for i := 0; i < len(keyvals); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(fmt.Sprint(keyvals[i]))
+		v, err := json.Marshal(logValue(keyvals[i+1]))
+		if err != nil {
+			v, _ = json.Marshal(fmt.Sprint(keyvals[i+1]))
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+}
+
+// logValue turns errors, durations and other stringers into their text.
+func logValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// LogFields are the fields correlating the log lines of the handling of msg, including the tasks it queues.
+func (msg Message) LogFields() []interface{} {
+	return []interface{}{"cid", msg.CorrelationID, "user", msg.UserID, "channel", msg.ChannelID}
+}
+
+// MessageLogger returns the logger of bot adding the correlation fields of msg to every line.
+func MessageLogger(bot Bot, msg Message) Logger {
+	return bot.GetLogger().With(msg.LogFields()...)
+}
@@ -0,0 +1,71 @@
+package gobot
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	// the time is replaced as it changes
+	timePattern := regexp.MustCompile(`\d{4}-\d\d-\d\dT[0-9:.]+(Z|[+-]\d\d:\d\d)`)
+
+	tests := []struct {
+		name   string
+		format string
+		log    func(logger Logger)
+		want   string
+	}{
+		{
+			name:   "logfmt",
+			format: FormatLogfmt,
+			log: func(logger Logger) {
+				logger.With("cid", "1.1", "task", 12).Info("task finished", "duration", 2*time.Second, "err", errors.New("exit status 1"))
+			},
+			want: "time=TIME level=info msg=\"task finished\" cid=1.1 task=12 duration=2s err=\"exit status 1\"\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			log: func(logger Logger) {
+				logger.With("cid", "1.1").Warn("handled", "text", `say "hi"`, "ok", true)
+			},
+			want: `{"time":"TIME","level":"warn","msg":"handled","cid":"1.1","text":"say \"hi\"","ok":true}` + "\n",
+		},
+		{
+			name:   "below level",
+			format: FormatLogfmt,
+			log: func(logger Logger) {
+				logger.Debug("message received")
+			},
+		},
+		{
+			name:   "missing value",
+			format: FormatLogfmt,
+			log: func(logger Logger) {
+				logger.Error("panic", "where")
+			},
+			want: "time=TIME level=error msg=panic where=MISSING\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(NewLogger(&buf, LevelInfo, tt.format))
+			if got := timePattern.ReplaceAllString(buf.String(), "TIME"); got != tt.want {
+				t.Errorf("log = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel("WARN"); err != nil || l != LevelWarn {
+		t.Errorf("ParseLevel() = %v, %v, want %v", l, err, LevelWarn)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("ParseLevel() = nil error, want error")
+	}
+}
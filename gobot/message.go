@@ -16,45 +16,91 @@ const (
 
 var (
 	space = regexp.MustCompile(`[\s　]+`)
-	url   = regexp.MustCompile(`<(https?://[^>]+)>`)
 )
 
 type Message struct {
 	Type MsgType
+	// Text is the plain text, the entities in it are decoded, e.g. <@U123> into @U123
 	Text string
 
+	// Users, Channels, Groups and Links are the entities in the text in order, mentions of the bot addressing it are left out
+	Users    []Entity
+	Channels []Entity
+	Groups   []Entity
+	Links    []Link
+
 	ChannelID string
 	UserID    string
+
+	TS       string
+	ThreadTS string
+	// Edited tells the message is an edit within the edit window of a message dispatched already
+	Edited bool
+
+	// Handler is the name of the handler the message is dispatched to
+	Handler string
+	// Action is the slash command or the button the message comes from, it's nil for typed messages
+	Action *Action
+
+	// Workspace is the name of the workspace of the bot the message is received by
+	Workspace string
+
+	// Lang is the language replies to the message are in
+	Lang string
+
+	// CorrelationID identifies the message in logs, it's the timestamp unless the message has none
+	CorrelationID string
+}
+
+func (msg Message) Ref() MessageRef {
+	return MessageRef{ChannelID: msg.ChannelID, TS: msg.TS}
+}
+
+// T translates the message of key into the language of msg.
+func (msg Message) T(key string, args ...interface{}) string {
+	return Translate(msg.Lang, key, args...)
+}
+
+// TErr translates err into the language of msg if it's an *Error, it's err.Error() otherwise.
+func (msg Message) TErr(err error) string {
+	if e, ok := err.(*Error); ok {
+		return Translate(msg.Lang, e.Key, e.Args...)
+	}
+	return err.Error()
+}
+
+// Thread returns the timestamp of the thread replies to the message should go into.
+func (msg Message) Thread() string {
+	if len(msg.ThreadTS) > 0 {
+		return msg.ThreadTS
+	}
+	return msg.TS
 }
 
 type MessageParser struct {
 	replyPrefix string
+	triggers    Triggers
 }
 
 func simplify(text string) string {
-	text = space.ReplaceAllString(text, " ")
-	for _, m := range url.FindAllStringSubmatch(text, -1) {
-		text = strings.ReplaceAll(text, m[0], m[1])
-	}
-	return strings.Trim(text, " ")
+	return strings.Trim(space.ReplaceAllString(text, " "), " ")
 }
 
 func (parser *MessageParser) Parse(msg, channelID, userID string) Message {
 	msg = simplify(msg)
 
+	text, addressed := parser.address(msg)
+	parsed := Message{Type: ListenTo, Text: msg, ChannelID: channelID, UserID: userID}
 	if channelID[0] == 'D' {
-		return Message{Type: DirectMessage, Text: msg, ChannelID: channelID, UserID: userID}
-	}
-	if strings.HasPrefix(msg, parser.replyPrefix) {
-		text := msg[len(parser.replyPrefix):]
-		if len(text) > 0 {
-			text = text[1:]
-		}
-		return Message{Type: ReplyTo, Text: text, ChannelID: channelID, UserID: userID}
+		parsed.Type, parsed.Text = DirectMessage, text
+	} else if addressed {
+		parsed.Type, parsed.Text = ReplyTo, text
 	}
-	return Message{Type: ListenTo, Text: msg, ChannelID: channelID, UserID: userID}
+	decodeEntities(&parsed)
+	return parsed
 }
 
-func NewMessageParser(botUserID string) *MessageParser {
-	return &MessageParser{replyPrefix: "<@" + botUserID + ">"}
+// NewMessageParser makes a parser of the messages to the bot of botUserID, addressed by a leading mention or triggers.
+func NewMessageParser(botUserID string, triggers Triggers) *MessageParser {
+	return &MessageParser{replyPrefix: "<@" + botUserID + ">", triggers: triggers}
 }
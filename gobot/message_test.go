@@ -8,6 +8,7 @@ import (
 func TestMessageParser_Parse(t *testing.T) {
 	type fields struct {
 		replyPrefix string
+		triggers    Triggers
 	}
 	type args struct {
 		msg       string
@@ -51,16 +52,91 @@ func TestMessageParser_Parse(t *testing.T) {
 			want:   Message{Type: ListenTo, Text: "hello world", ChannelID: "X123", UserID: "U123"},
 		},
 		{
-			name:   "simplify message",
+			name:   "decode links",
 			fields: fields{replyPrefix: "<@PREFIX>"},
 			args:   args{userID: "U123", channelID: "X123", msg: "<http://test.com> <https://example.com/test>"},
-			want:   Message{Type: ListenTo, Text: "http://test.com https://example.com/test", ChannelID: "X123", UserID: "U123"},
+			want: Message{Type: ListenTo, Text: "http://test.com https://example.com/test", ChannelID: "X123", UserID: "U123",
+				Links: []Link{{URL: "http://test.com"}, {URL: "https://example.com/test"}}},
+		},
+		{
+			name:   "reply to - prefix",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Prefixes: []string{"!"}}},
+			args:   args{userID: "U123", channelID: "X123", msg: "!dist-beta --branch foo"},
+			want:   Message{Type: ReplyTo, Text: "dist-beta --branch foo", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "listen to - prefix only",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Prefixes: []string{"!"}}},
+			args:   args{userID: "U123", channelID: "X123", msg: "!"},
+			want:   Message{Type: ListenTo, Text: "!", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "reply to - alias",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Aliases: []string{"gobot"}}},
+			args:   args{userID: "U123", channelID: "X123", msg: "GoBot: ps"},
+			want:   Message{Type: ReplyTo, Text: "ps", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "reply to - alias with @",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Aliases: []string{"gobot"}}},
+			args:   args{userID: "U123", channelID: "X123", msg: "@gobot ps"},
+			want:   Message{Type: ReplyTo, Text: "ps", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "listen to - alias in a word",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Aliases: []string{"gobot"}}},
+			args:   args{userID: "U123", channelID: "X123", msg: "gobots are cool"},
+			want:   Message{Type: ListenTo, Text: "gobots are cool", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "reply to - mention anywhere",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{MentionAnywhere: true}},
+			args:   args{userID: "U123", channelID: "X123", msg: "could you <@PREFIX> ps"},
+			want:   Message{Type: ReplyTo, Text: "ps", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "reply to - mention at the end",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{MentionAnywhere: true}},
+			args:   args{userID: "U123", channelID: "X123", msg: "ps <@PREFIX>"},
+			want:   Message{Type: ReplyTo, Text: "ps", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "listen to - mention anywhere disabled",
+			fields: fields{replyPrefix: "<@PREFIX>"},
+			args:   args{userID: "U123", channelID: "X123", msg: "ps <@PREFIX>"},
+			want:   Message{Type: ListenTo, Text: "ps @PREFIX", ChannelID: "X123", UserID: "U123", Users: []Entity{{ID: "PREFIX"}}},
+		},
+		{
+			name:   "entities",
+			fields: fields{replyPrefix: "<@PREFIX>"},
+			args: args{userID: "U123", channelID: "X123",
+				msg: "<@PREFIX> review <@U456> <@U789|bob> in <#C123|general> <#C456> cc <!subteam^S123|@devs> <!here> <mailto:a@example.com|a@example.com> <https://example.com/?a=1&amp;b=2|example.com>"},
+			want: Message{Type: ReplyTo, ChannelID: "X123", UserID: "U123",
+				Text:     "review @U456 @bob in #general #C456 cc @devs @here a@example.com example.com",
+				Users:    []Entity{{ID: "U456"}, {ID: "U789", Name: "bob"}},
+				Channels: []Entity{{ID: "C123", Name: "general"}, {ID: "C456"}},
+				Groups:   []Entity{{ID: "S123", Name: "devs"}},
+				Links:    []Link{{URL: "mailto:a@example.com", Label: "a@example.com"}, {URL: "https://example.com/?a=1&b=2", Label: "example.com"}},
+			},
+		},
+		{
+			name:   "html escapes",
+			fields: fields{replyPrefix: "<@PREFIX>"},
+			args:   args{userID: "U123", channelID: "X123", msg: "<@PREFIX> dist-beta --branch feature&amp;fix --msg &lt;b&gt; &amp;lt;"},
+			want:   Message{Type: ReplyTo, Text: "dist-beta --branch feature&fix --msg <b> &lt;", ChannelID: "X123", UserID: "U123"},
+		},
+		{
+			name:   "direct message - prefix",
+			fields: fields{replyPrefix: "<@PREFIX>", triggers: Triggers{Prefixes: []string{"!"}}},
+			args:   args{userID: "U123", channelID: "D123", msg: "!ps"},
+			want:   Message{Type: DirectMessage, Text: "ps", ChannelID: "D123", UserID: "U123"},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			parser := &MessageParser{
 				replyPrefix: tt.fields.replyPrefix,
+				triggers:    tt.fields.triggers,
 			}
 			if got := parser.Parse(tt.args.msg, tt.args.channelID, tt.args.userID); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("MessageParser.Parse() = %v, want %v", got, tt.want)
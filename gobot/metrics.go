@@ -0,0 +1,31 @@
+package gobot
+
+import (
+	"time"
+
+	"github.com/li-go/gobot/metrics"
+)
+
+var (
+	messagesReceived = metrics.Default.NewCounterVec("gobot_messages_received_total", "Messages received by type.", "type")
+	handlerCalls     = metrics.Default.NewCounterVec("gobot_handler_invocations_total", "Handler invocations by handler.", "handler")
+	handlerErrors    = metrics.Default.NewCounterVec("gobot_handler_errors_total", "Handler errors by handler.", "handler")
+	handlerLatency   = metrics.Default.NewHistogramVec("gobot_handler_duration_seconds", "Handler latency by handler.", metrics.DefBuckets, "handler")
+	reconnects       = metrics.Default.NewCounterVec("gobot_reconnects_total", "Reconnections of the transport.")
+)
+
+// Metrics records the invocations, errors and latency of every handler.
+func Metrics() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(bot Bot, msg Message) error {
+			start := time.Now()
+			err := next(bot, msg)
+			handlerCalls.Inc(msg.Handler)
+			if err != nil {
+				handlerErrors.Inc(msg.Handler)
+			}
+			handlerLatency.Observe(time.Since(start).Seconds(), msg.Handler)
+			return err
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package gobot
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRateLimited  = NewError("error.rate_limited")
+	ErrNoPermission = NewError("error.no_permission")
+)
+
+type HandlerFunc func(bot Bot, msg Message) error
+
+// Middleware wraps the handling of every message, it's registered by Bot.Use.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+func chain(h HandlerFunc, middlewares []Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Logging logs every handled message with its handler, duration and error.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(bot Bot, msg Message) error {
+			start := time.Now()
+			err := next(bot, msg)
+			logger := MessageLogger(bot, msg)
+			if err != nil {
+				logger.Warn("handled", "text", msg.Text, "handler", msg.Handler, "duration", time.Since(start), "err", err)
+			} else {
+				logger.Info("handled", "text", msg.Text, "handler", msg.Handler, "duration", time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Recovery reports a panic of the handler and turns it into an error.
+func Recovery() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(bot Bot, msg Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					bot.ReportPanic(fmt.Sprintf("%s: `%s` by <@%s>", msg.Handler, msg.Text, msg.UserID), r, debug.Stack())
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(bot, msg)
+		}
+	}
+}
+
+// RateLimit allows each user at most n handled messages within duration d.
+func RateLimit(n int, d time.Duration) Middleware {
+	limiter := &rateLimiter{n: n, d: d, history: make(map[string][]time.Time)}
+	return func(next HandlerFunc) HandlerFunc {
+		return func(bot Bot, msg Message) error {
+			if !limiter.allow(msg.UserID, time.Now()) {
+				return ErrRateLimited
+			}
+			return next(bot, msg)
+		}
+	}
+}
+
+// rateLimiter keeps the times of the messages of each user within d.
+type rateLimiter struct {
+	n int
+	d time.Duration
+
+	mutex      sync.Mutex
+	history    map[string][]time.Time
+	lastPruned time.Time
+}
+
+func (l *rateLimiter) allow(userID string, now time.Time) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	// forget the users quiet for d, at most once per d
+	if now.Sub(l.lastPruned) >= l.d {
+		for id, times := range l.history {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) >= l.d {
+				delete(l.history, id)
+			}
+		}
+		l.lastPruned = now
+	}
+	var recent []time.Time
+	for _, t := range l.history[userID] {
+		if now.Sub(t) < l.d {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= l.n {
+		l.history[userID] = recent
+		return false
+	}
+	l.history[userID] = append(recent, now)
+	return true
+}
+
+// Permission allows only the listed users (by ID, e.g. "U123", as display names can be taken by anyone) to use a handler,
+// handlers missing from users are open to everyone.
+func Permission(users map[string][]string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(bot Bot, msg Message) error {
+			allowed, ok := users[msg.Handler]
+			if !ok {
+				return next(bot, msg)
+			}
+			for _, userID := range allowed {
+				if userID == msg.UserID {
+					return next(bot, msg)
+				}
+			}
+			return ErrNoPermission
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package gobot
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/metrics"
+)
+
+func newTestBot(t *testing.T, opts ...Option) Bot {
+	b, err := New(newFakeTransport(), NewLogger(ioutil.Discard, LevelError, FormatLogfmt), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestChain(t *testing.T) {
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(bot Bot, msg Message) error {
+				calls = append(calls, name)
+				return next(bot, msg)
+			}
+		}
+	}
+	h := chain(func(bot Bot, msg Message) error {
+		calls = append(calls, "handler")
+		return nil
+	}, []Middleware{mw("first"), mw("second")})
+	if err := h(newTestBot(t), Message{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second", "handler"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	h := Recovery()(func(bot Bot, msg Message) error {
+		var m map[string]int
+		m["a"]++
+		return nil
+	})
+	if err := h(newTestBot(t), Message{}); err == nil {
+		t.Error("Recovery() error = nil, want panic error")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := Metrics()(func(bot Bot, msg Message) error {
+		if msg.Text == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	b := newTestBot(t)
+	for _, text := range []string{"ok", "fail", "ok"} {
+		_ = h(b, Message{Text: text, Handler: "metrics-test"})
+	}
+
+	var buf bytes.Buffer
+	if err := metrics.Default.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`gobot_handler_invocations_total{handler="metrics-test"} 3`,
+		`gobot_handler_errors_total{handler="metrics-test"} 1`,
+		`gobot_handler_duration_seconds_count{handler="metrics-test"} 3`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("metrics missing %v", want)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit(2, time.Minute)(func(bot Bot, msg Message) error {
+		return nil
+	})
+	b := newTestBot(t)
+	tests := []struct {
+		userID string
+		want   error
+	}{
+		{userID: "U1", want: nil},
+		{userID: "U1", want: nil},
+		{userID: "U1", want: ErrRateLimited},
+		{userID: "U2", want: nil},
+	}
+	for _, tt := range tests {
+		if err := h(b, Message{UserID: tt.userID}); err != tt.want {
+			t.Errorf("RateLimit() %s error = %v, want %v", tt.userID, err, tt.want)
+		}
+	}
+}
+
+func TestRateLimit_prune(t *testing.T) {
+	l := &rateLimiter{n: 2, d: time.Minute, history: make(map[string][]time.Time)}
+	now := time.Now()
+	l.allow("U1", now)
+	l.allow("U2", now.Add(30*time.Second))
+	l.allow("U3", now.Add(time.Minute))
+	if got, want := len(l.history), 2; got != want {
+		t.Errorf("users = %v, want %v", got, want)
+	}
+	l.allow("U3", now.Add(3*time.Minute))
+	if _, ok := l.history["U2"]; ok || len(l.history) != 1 {
+		t.Errorf("history = %v, want only U3", l.history)
+	}
+}
+
+func TestPermission(t *testing.T) {
+	errHandled := errors.New("handled")
+	h := Permission(map[string][]string{"kill": {"U1"}, "deploy": {"U2"}})(func(bot Bot, msg Message) error {
+		return errHandled
+	})
+	tests := []struct {
+		handler string
+		userID  string
+		want    error
+	}{
+		{handler: "kill", userID: "U1", want: errHandled},
+		{handler: "deploy", userID: "U1", want: ErrNoPermission},
+		{handler: "deploy", userID: "U2", want: errHandled},
+		// display names don't count, anyone can take one
+		{handler: "deploy", userID: "@display", want: ErrNoPermission},
+		{handler: "ps", userID: "U1", want: errHandled},
+	}
+	for _, tt := range tests {
+		if err := h(newTestBot(t), Message{UserID: tt.userID, Handler: tt.handler}); err != tt.want {
+			t.Errorf("Permission() %s by %s error = %v, want %v", tt.handler, tt.userID, err, tt.want)
+		}
+	}
+}
+
+func TestBot_Permission(t *testing.T) {
+	transport := newFakeTransport()
+	bb, err := New(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := bb.(*bot)
+	b.Use(Permission(map[string][]string{"deploy": {"U1"}}))
+	err = b.RegisterHandler(Handler{
+		Name: "deploy",
+		Help: "deploy",
+		Handleable: func(bot Bot, msg Message) bool {
+			return msg.Text == "deploy"
+		},
+		Handle: func(bot Bot, msg Message) error {
+			_, err := bot.SendMessage("deployed", msg.ChannelID)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		{userID: "U1", want: "C123: deployed"},
+		{userID: "U2", want: "C123: <@U2> *failed* - `deploy` :see_no_evil: (error: no permission)"},
+	}
+	for _, tt := range tests {
+		b.onMessage(&MessageEvent{ChannelID: "C123", UserID: tt.userID, Text: "deploy", TS: "1.1"})
+		b.inflight.Wait()
+		if got := <-transport.sent; got != tt.want {
+			t.Errorf("sent = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package gobot
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxMessageLength is the longest text sent in one message, longer ones are split
+	maxMessageLength = 3000
+	// maxMessageParts is the most messages a text is split into, longer ones are uploaded as a file
+	maxMessageParts = 4
+	// each channel gets sendBurst messages at once, then one every sendInterval
+	sendInterval = time.Second
+	sendBurst    = 3
+	// maxSendRetries is how many times a message is retried when rate limited by slack
+	maxSendRetries = 3
+
+	uploadFilename = "output.txt"
+	codeFence      = "```"
+)
+
+type outboxJob struct {
+	msg OutgoingMessage
+	// result receives the result of a sent message, it's nil for posted messages
+	result chan outboxResult
+}
+
+type outboxResult struct {
+	ref MessageRef
+	err error
+}
+
+// outbox sends the messages of each channel in order and rate-limits them,
+// texts too long for a message are split or uploaded as a file.
+type outbox struct {
+	transport Transport
+	logger    Logger
+	interval  time.Duration
+	burst     int
+
+	mutex   sync.Mutex
+	queues  map[string]*outboxQueue
+	pending sync.WaitGroup
+	// waiting counts the messages not sent yet, like pending
+	waiting int
+}
+
+// outboxQueue holds the messages of a channel, they are sent by one goroutine while there are any.
+type outboxQueue struct {
+	jobs    []outboxJob
+	running bool
+	// tokens are the messages that can be sent at once, refilled one every interval up to burst
+	tokens float64
+	last   time.Time
+}
+
+func newOutbox(transport Transport, logger Logger, interval time.Duration, burst int) *outbox {
+	return &outbox{
+		transport: transport,
+		logger:    logger,
+		interval:  interval,
+		burst:     burst,
+		queues:    make(map[string]*outboxQueue),
+	}
+}
+
+// send queues msg and waits until it's sent, the ref is the one of the first part of a split message.
+func (o *outbox) send(msg OutgoingMessage) (MessageRef, error) {
+	result := make(chan outboxResult, 1)
+	o.enqueue(outboxJob{msg: msg, result: result})
+	r := <-result
+	return r.ref, r.err
+}
+
+// post queues msg without waiting, consecutive posted messages to the same place may be merged into one.
+func (o *outbox) post(msg OutgoingMessage) {
+	o.enqueue(outboxJob{msg: msg})
+}
+
+func (o *outbox) enqueue(job outboxJob) {
+	o.pending.Add(1)
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.waiting++
+	q, ok := o.queues[job.msg.ChannelID]
+	if !ok {
+		q = &outboxQueue{tokens: float64(o.burst), last: time.Now()}
+		o.queues[job.msg.ChannelID] = q
+	}
+	q.jobs = append(q.jobs, job)
+	if !q.running {
+		q.running = true
+		go o.run(q)
+	}
+}
+
+// queued returns how many messages are waiting to be sent.
+func (o *outbox) queued() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.waiting
+}
+
+// wait waits for the queued messages to be sent until done is closed, it returns false then.
+func (o *outbox) wait(done <-chan struct{}) bool {
+	drained := make(chan struct{})
+	go func() {
+		o.pending.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+func (o *outbox) run(q *outboxQueue) {
+	for {
+		o.mutex.Lock()
+		if len(q.jobs) == 0 {
+			q.running = false
+			o.mutex.Unlock()
+			return
+		}
+		job, n := mergeJobs(q.jobs)
+		q.jobs = q.jobs[n:]
+		o.mutex.Unlock()
+
+		ref, err := o.deliver(q, job.msg)
+		if job.result != nil {
+			job.result <- outboxResult{ref: ref, err: err}
+		} else if err != nil {
+			o.logger.Error("fail to send message", "channel", job.msg.ChannelID, "err", err)
+		}
+		o.mutex.Lock()
+		o.waiting -= n
+		o.mutex.Unlock()
+		for i := 0; i < n; i++ {
+			o.pending.Done()
+		}
+	}
+}
+
+func (o *outbox) deliver(q *outboxQueue, msg OutgoingMessage) (MessageRef, error) {
+	parts, ok := splitMessage(msg)
+	if !ok {
+		o.take(q)
+		return o.transport.UploadFile(uploadOf(msg))
+	}
+	var first MessageRef
+	for i, part := range parts {
+		if part.Rich != nil && len(part.Text) == 0 {
+			part.Text = part.Rich.PlainText()
+		}
+		o.take(q)
+		ref, err := o.sendWithRetry(part)
+		if err != nil {
+			return first, err
+		}
+		if i == 0 {
+			first = ref
+		}
+	}
+	return first, nil
+}
+
+// take takes a token of the channel, waiting for one if there is none left.
+func (o *outbox) take(q *outboxQueue) {
+	now := time.Now()
+	q.tokens = math.Min(float64(o.burst), q.tokens+float64(now.Sub(q.last))/float64(o.interval))
+	q.last = now
+	if q.tokens < 1 {
+		time.Sleep(time.Duration((1 - q.tokens) * float64(o.interval)))
+		q.tokens = 1
+		q.last = time.Now()
+	}
+	q.tokens--
+}
+
+func (o *outbox) sendWithRetry(msg OutgoingMessage) (MessageRef, error) {
+	for i := 0; ; i++ {
+		ref, err := o.transport.SendMessage(msg)
+		limited, ok := err.(*RateLimitedError)
+		if !ok || i >= maxSendRetries {
+			return ref, err
+		}
+		o.logger.Warn("rate limited", "channel", msg.ChannelID, "retry_after", limited.RetryAfter)
+		time.Sleep(limited.RetryAfter)
+	}
+}
+
+// mergeJobs merges the posted messages at the head of jobs, and returns the merged job and how many jobs it covers.
+func mergeJobs(jobs []outboxJob) (outboxJob, int) {
+	job := jobs[0]
+	if job.result != nil {
+		return job, 1
+	}
+	n := 1
+	for ; n < len(jobs) && jobs[n].result == nil; n++ {
+		merged, ok := mergeMessages(job.msg, jobs[n].msg)
+		if !ok {
+			break
+		}
+		job.msg = merged
+	}
+	return job, n
+}
+
+// mergeMessages joins the texts of two plain messages, or of two rich messages with the same single section title,
+// e.g. the lines a command writes to stderr.
+func mergeMessages(a, b OutgoingMessage) (OutgoingMessage, bool) {
+	if a.ChannelID != b.ChannelID || a.ThreadTS != b.ThreadTS {
+		return a, false
+	}
+	if a.Rich == nil && b.Rich == nil {
+		a.Text = joinTexts(a.Text, b.Text)
+		return a, true
+	}
+	if a.Rich == nil || b.Rich == nil || len(a.Text) > 0 || len(b.Text) > 0 || !isSplittable(*a.Rich) || !isSplittable(*b.Rich) {
+		return a, false
+	}
+	sa, sb := a.Rich.Sections[0], b.Rich.Sections[0]
+	if a.Rich.Color != b.Rich.Color || sa.Title != sb.Title || sa.Context != sb.Context {
+		return a, false
+	}
+	rich := *a.Rich
+	sa.Text = joinTexts(sa.Text, sb.Text)
+	rich.Sections = []Section{sa}
+	a.Rich = &rich
+	return a, true
+}
+
+// joinTexts joins two texts by a line break, adjacent code blocks are fused into one.
+func joinTexts(a, b string) string {
+	if strings.HasSuffix(a, "\n"+codeFence) && strings.HasPrefix(b, codeFence+"\n") {
+		return a[:len(a)-len(codeFence)] + b[len(codeFence)+1:]
+	}
+	return a + "\n" + b
+}
+
+// isSplittable tells whether the rich message is a single section of text, whose text can be split or merged.
+func isSplittable(m RichMessage) bool {
+	return len(m.Sections) == 1 && len(m.Sections[0].Fields) == 0 && len(m.Buttons) == 0
+}
+
+// splitMessage splits the text of msg into messages short enough,
+// it returns false if the text is too long even for maxMessageParts messages.
+func splitMessage(msg OutgoingMessage) ([]OutgoingMessage, bool) {
+	if msg.Rich == nil {
+		texts := splitText(msg.Text, maxMessageLength)
+		if len(texts) > maxMessageParts {
+			return nil, false
+		}
+		var parts []OutgoingMessage
+		for _, text := range texts {
+			part := msg
+			part.Text = text
+			parts = append(parts, part)
+		}
+		return parts, true
+	}
+	if !isSplittable(*msg.Rich) {
+		return []OutgoingMessage{msg}, true
+	}
+	section := msg.Rich.Sections[0]
+	texts := splitText(section.Text, maxMessageLength)
+	if len(texts) > maxMessageParts {
+		return nil, false
+	}
+	var parts []OutgoingMessage
+	for i, text := range texts {
+		s := section
+		s.Text = text
+		// the title once at the top, the context once at the bottom
+		if i > 0 {
+			s.Title = ""
+		}
+		if i < len(texts)-1 {
+			s.Context = ""
+		}
+		rich := *msg.Rich
+		rich.Sections = []Section{s}
+		part := msg
+		part.Rich = &rich
+		if len(texts) > 1 {
+			part.Text = ""
+		}
+		parts = append(parts, part)
+	}
+	return parts, true
+}
+
+// uploadOf makes the file a message too long to be sent is uploaded as.
+func uploadOf(msg OutgoingMessage) FileUpload {
+	title, text := "output", msg.Text
+	if msg.Rich != nil && isSplittable(*msg.Rich) {
+		if len(msg.Rich.Sections[0].Title) > 0 {
+			title = msg.Rich.Sections[0].Title
+		}
+		text = msg.Rich.Sections[0].Text
+	} else if msg.Rich != nil && len(text) == 0 {
+		text = msg.Rich.PlainText()
+	}
+	// the file shows the text as is, the code fences would be noise
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != codeFence {
+			lines = append(lines, line)
+		}
+	}
+	return FileUpload{
+		ChannelID: msg.ChannelID,
+		ThreadTS:  msg.ThreadTS,
+		Filename:  uploadFilename,
+		Title:     title,
+		Content:   strings.Join(lines, "\n"),
+	}
+}
+
+// splitText splits text at line boundaries into parts of at most limit bytes,
+// a code block split across parts is closed at the end of one part and reopened at the start of the next.
+func splitText(text string, limit int) []string {
+	if len(text) <= limit {
+		return []string{text}
+	}
+	// room for the fences reopening and closing a code block
+	reserve := 2 * (len(codeFence) + 1)
+
+	var parts, lines []string
+	var size, content int
+	var inFence bool
+	for _, line := range splitLongLines(strings.Split(text, "\n"), limit-reserve) {
+		fenceAfter := inFence != (strings.Count(line, codeFence)%2 == 1)
+		newSize := size + len(line)
+		if len(lines) > 0 {
+			newSize++
+		}
+		closing := 0
+		if fenceAfter {
+			closing = len(codeFence) + 1
+		}
+		if newSize+closing > limit && content > 0 {
+			part := strings.Join(lines, "\n")
+			lines, size, content = nil, 0, 0
+			if inFence {
+				part += "\n" + codeFence
+				lines, size = []string{codeFence}, len(codeFence)
+			}
+			parts = append(parts, part)
+			newSize = size + len(line)
+			if len(lines) > 0 {
+				newSize++
+			}
+		}
+		lines = append(lines, line)
+		size = newSize
+		content++
+		inFence = fenceAfter
+	}
+	if content > 0 {
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return parts
+}
+
+// splitLongLines breaks the lines longer than max bytes at rune boundaries.
+func splitLongLines(lines []string, max int) []string {
+	var split []string
+	for _, line := range lines {
+		for len(line) > max {
+			i := max
+			for i > 0 && !utf8.RuneStart(line[i]) {
+				i--
+			}
+			split = append(split, line[:i])
+			line = line[i:]
+		}
+		split = append(split, line)
+	}
+	return split
+}
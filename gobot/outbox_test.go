@@ -0,0 +1,185 @@
+package gobot
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			text:  "short",
+			limit: 20,
+			want:  []string{"short"},
+		},
+		{
+			text:  "line one\nline two\nline three",
+			limit: 20,
+			want:  []string{"line one\nline two", "line three"},
+		},
+		{
+			text:  "log:\n```\naaaaaaaa\nbbbbbbbb\ncccccccc\n```\ndone",
+			limit: 24,
+			want:  []string{"log:\n```\naaaaaaaa\n```", "```\nbbbbbbbb\n```", "```\ncccccccc\n```\ndone"},
+		},
+		{
+			text:  strings.Repeat("x", 25),
+			limit: 18,
+			want:  []string{"xxxxxxxxxx", "xxxxxxxxxx\nxxxxx"},
+		},
+		{
+			text:  "ああああああ\nい",
+			limit: 16,
+			want:  []string{"ああ\nああ", "ああ\nい"},
+		},
+	}
+	for _, tt := range tests {
+		got := splitText(tt.text, tt.limit)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+		for _, part := range got {
+			if len(part) > tt.limit {
+				t.Errorf("splitText(%q, %d): part %q is too long", tt.text, tt.limit, part)
+			}
+			if strings.Count(part, codeFence)%2 != 0 {
+				t.Errorf("splitText(%q, %d): part %q has unbalanced code fences", tt.text, tt.limit, part)
+			}
+		}
+	}
+}
+
+func TestMergeMessages(t *testing.T) {
+	errorMsg := func(text string) *RichMessage {
+		return &RichMessage{Color: ColorDanger, Sections: []Section{{Title: "error", Text: "```\n" + text + "\n```"}}}
+	}
+	tests := []struct {
+		a, b   OutgoingMessage
+		want   OutgoingMessage
+		wantOK bool
+	}{
+		{
+			a:      OutgoingMessage{ChannelID: "C123", Text: "a"},
+			b:      OutgoingMessage{ChannelID: "C123", Text: "b"},
+			want:   OutgoingMessage{ChannelID: "C123", Text: "a\nb"},
+			wantOK: true,
+		},
+		{
+			a:      OutgoingMessage{ChannelID: "C123", Rich: errorMsg("a")},
+			b:      OutgoingMessage{ChannelID: "C123", Rich: errorMsg("b")},
+			want:   OutgoingMessage{ChannelID: "C123", Rich: errorMsg("a\nb")},
+			wantOK: true,
+		},
+		{
+			a:    OutgoingMessage{ChannelID: "C123", Text: "a"},
+			b:    OutgoingMessage{ChannelID: "C123", ThreadTS: "1.1", Text: "b"},
+			want: OutgoingMessage{ChannelID: "C123", Text: "a"},
+		},
+		{
+			a:    OutgoingMessage{ChannelID: "C123", Text: "a"},
+			b:    OutgoingMessage{ChannelID: "C123", Rich: errorMsg("b")},
+			want: OutgoingMessage{ChannelID: "C123", Text: "a"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := mergeMessages(tt.a, tt.b)
+		if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mergeMessages(%+v, %+v) = %+v, %v, want %+v, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	transport := newFakeTransport()
+	o := newOutbox(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt), 50*time.Millisecond, 2)
+
+	start := time.Now()
+	for _, text := range []string{"1", "2", "3"} {
+		if _, err := o.send(OutgoingMessage{ChannelID: "C123", Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the third message waits for a token
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("3 messages sent in %v, want rate limited", d)
+	}
+
+	long := strings.Repeat("line\n", maxMessageLength*maxMessageParts/5) + "end"
+	o.post(OutgoingMessage{ChannelID: "C123", Text: long})
+	o.wait(make(chan struct{}))
+
+	var got []string
+	for len(transport.sent) > 0 {
+		got = append(got, <-transport.sent)
+	}
+	want := []string{"C123: 1", "C123: 2", "C123: 3", "C123: output.txt\n" + long}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sent = %q, want %q", got, want)
+	}
+}
+
+func TestMergeJobs(t *testing.T) {
+	result := make(chan outboxResult)
+	jobs := []outboxJob{
+		{msg: OutgoingMessage{ChannelID: "C123", Text: "a"}},
+		{msg: OutgoingMessage{ChannelID: "C123", Text: "b"}},
+		{msg: OutgoingMessage{ChannelID: "C123", Text: "c"}, result: result},
+		{msg: OutgoingMessage{ChannelID: "C123", Text: "d"}},
+	}
+	tests := []struct {
+		from     int
+		wantText string
+		wantN    int
+	}{
+		{from: 0, wantText: "a\nb", wantN: 2},
+		// sent messages are never merged
+		{from: 2, wantText: "c", wantN: 1},
+		{from: 3, wantText: "d", wantN: 1},
+	}
+	for _, tt := range tests {
+		job, n := mergeJobs(jobs[tt.from:])
+		if job.msg.Text != tt.wantText || n != tt.wantN {
+			t.Errorf("mergeJobs(jobs[%d:]) = %q, %d, want %q, %d", tt.from, job.msg.Text, n, tt.wantText, tt.wantN)
+		}
+	}
+}
+
+type rateLimitedTransport struct {
+	*fakeTransport
+	mutex   sync.Mutex
+	limited int
+}
+
+func (t *rateLimitedTransport) SendMessage(msg OutgoingMessage) (MessageRef, error) {
+	t.mutex.Lock()
+	if t.limited > 0 {
+		t.limited--
+		t.mutex.Unlock()
+		return MessageRef{}, &RateLimitedError{RetryAfter: time.Millisecond}
+	}
+	t.mutex.Unlock()
+	return t.fakeTransport.SendMessage(msg)
+}
+
+func TestOutbox_retry(t *testing.T) {
+	transport := &rateLimitedTransport{fakeTransport: newFakeTransport(), limited: 2}
+	o := newOutbox(transport, NewLogger(ioutil.Discard, LevelError, FormatLogfmt), time.Millisecond, 1)
+	ref, err := o.send(OutgoingMessage{ChannelID: "C123", Text: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (MessageRef{ChannelID: "C123", TS: "1.1"}); ref != want {
+		t.Errorf("ref = %v, want %v", ref, want)
+	}
+	if got, want := <-transport.sent, "C123: hello"; got != want {
+		t.Errorf("sent = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package gobot
+
+import "strings"
+
+const (
+	ColorGood    = "good"
+	ColorWarning = "warning"
+	ColorDanger  = "danger"
+
+	ButtonPrimary = "primary"
+	ButtonDanger  = "danger"
+)
+
+// RichMessage is a structured message,
+// transports able to render it use blocks or attachments and the others fall back to PlainText.
+type RichMessage struct {
+	// Color shows the sections as attachments with a colored bar, e.g. ColorGood or "#439FE0"
+	Color    string
+	Sections []Section
+	Buttons  []Button
+}
+
+type Section struct {
+	Title   string
+	Text    string
+	Fields  []Field
+	Context string
+}
+
+type Field struct {
+	Title string
+	Value string
+}
+
+// Button is shown below the sections, clicking it sends Value to the bot
+// as if the user mentioned the bot with it, e.g. "kill 12".
+type Button struct {
+	Text  string
+	Value string
+	// Style is ButtonPrimary, ButtonDanger or empty
+	Style string
+}
+
+func (m RichMessage) PlainText() string {
+	var ss []string
+	for _, s := range m.Sections {
+		if len(s.Title) > 0 {
+			ss = append(ss, "*"+s.Title+"*")
+		}
+		if len(s.Text) > 0 {
+			ss = append(ss, s.Text)
+		}
+		for _, f := range s.Fields {
+			ss = append(ss, f.Title+": "+f.Value)
+		}
+		if len(s.Context) > 0 {
+			ss = append(ss, "_"+s.Context+"_")
+		}
+	}
+	return strings.Join(ss, "\n")
+}
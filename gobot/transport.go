@@ -0,0 +1,195 @@
+package gobot
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transport is the chat protocol the bot receives events from and sends messages through.
+type Transport interface {
+	// Connect authenticates the bot and returns its own identity.
+	Connect() (*Identity, error)
+	// Run keeps receiving events until Disconnect is called.
+	Run()
+	Disconnect() error
+	IncomingEvents() <-chan Event
+
+	// SendMessage returns a *RateLimitedError when slack throttles the bot
+	SendMessage(msg OutgoingMessage) (MessageRef, error)
+	// UploadFile shares a text file, e.g. an output too long for a message
+	UploadFile(file FileUpload) (MessageRef, error)
+	UpdateMessage(ref MessageRef, msg OutgoingMessage) error
+	DeleteMessage(ref MessageRef) error
+	AddReaction(name string, ref MessageRef) error
+	RemoveReaction(name string, ref MessageRef) error
+	GetUser(userID string) (*User, error)
+	GetChannel(channelID string) (*Channel, error)
+	// ListChannels returns the conversations the bot can see, they are used to warm the directory up.
+	ListChannels() ([]Channel, error)
+}
+
+type Identity struct {
+	UserID   string
+	UserName string
+}
+
+type User struct {
+	ID          string
+	Name        string
+	DisplayName string
+	StatusText  string
+	StatusEmoji string
+}
+
+type Channel struct {
+	ID   string
+	Name string
+	IsIM bool
+}
+
+// OutgoingMessage is a message sent by the bot,
+// it's posted into the thread of ThreadTS if given.
+type OutgoingMessage struct {
+	ChannelID string
+	ThreadTS  string
+	// Text is the plain text of Rich when it's given
+	Text string
+	Rich *RichMessage
+}
+
+// FileUpload is a text file shared into a channel, or the thread of ThreadTS if given.
+type FileUpload struct {
+	ChannelID string
+	ThreadTS  string
+	Filename  string
+	Title     string
+	Content   string
+}
+
+// RateLimitedError tells a request was throttled and can be retried after RetryAfter.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+}
+
+// MessageRef points to a message in a channel.
+type MessageRef struct {
+	ChannelID string
+	TS        string
+}
+
+// types of the events passed to event handlers
+const (
+	EventReactionAdded       = "reaction_added"
+	EventReactionRemoved     = "reaction_removed"
+	EventMemberJoinedChannel = "member_joined_channel"
+	EventMemberLeftChannel   = "member_left_channel"
+	EventChannelCreated      = "channel_created"
+	EventChannelRename       = "channel_rename"
+	EventUserChange          = "user_change"
+	EventIMCreated           = "im_created"
+	EventPinAdded            = "pin_added"
+	EventPinRemoved          = "pin_removed"
+	EventMessageDeleted      = "message_deleted"
+)
+
+type Event struct {
+	Type string
+	Data interface{}
+}
+
+type MessageEvent struct {
+	ChannelID string
+	UserID    string
+	BotID     string
+	Text      string
+	SubType   string
+	TS        string
+	ThreadTS  string
+	// Mention tells the message is addressed to the bot without mentioning it, e.g. a slash command
+	Mention bool
+	Action  *Action
+	// Edited tells the message is the new version of the message TS, whose text was PreviousText
+	Edited       bool
+	PreviousText string
+}
+
+// Action is the interaction a message comes from, e.g. a slash command or a clicked button.
+type Action struct {
+	// Command is the slash command, e.g. "/gobot"
+	Command string
+	// ID is the action id of the clicked button, and Value its value
+	ID    string
+	Value string
+	// Message is the message the clicked button belongs to
+	Message     MessageRef
+	ResponseURL string
+}
+
+type ConnectedEvent struct{}
+
+type DisconnectedEvent struct {
+	Intentional bool
+}
+
+// ErrorEvent reports a transport failure that didn't stop it from running.
+type ErrorEvent struct {
+	Err error
+}
+
+// ChannelRenameEvent tells a channel got a new name.
+type ChannelRenameEvent struct {
+	ChannelID string
+	Name      string
+}
+
+// UserChangeEvent tells the profile of a user changed, including the status.
+type UserChangeEvent struct {
+	User User
+}
+
+// IMCreatedEvent tells a direct message channel with a user was opened.
+type IMCreatedEvent struct {
+	ChannelID string
+	UserID    string
+}
+
+// ReactionEvent tells a user added or removed a reaction to a message of ItemUserID.
+type ReactionEvent struct {
+	UserID     string
+	Reaction   string
+	ItemUserID string
+	Item       MessageRef
+}
+
+// MemberEvent tells a user joined or left a channel.
+type MemberEvent struct {
+	ChannelID string
+	UserID    string
+}
+
+type ChannelCreatedEvent struct {
+	ChannelID string
+	Name      string
+	CreatorID string
+}
+
+// MessageDeletedEvent tells the message TS was deleted.
+type MessageDeletedEvent struct {
+	ChannelID string
+	TS        string
+}
+
+func (ev MessageDeletedEvent) Ref() MessageRef {
+	return MessageRef{ChannelID: ev.ChannelID, TS: ev.TS}
+}
+
+// PinEvent tells a user pinned or unpinned a message.
+type PinEvent struct {
+	ChannelID string
+	UserID    string
+	Item      MessageRef
+}
@@ -0,0 +1,60 @@
+package gobot
+
+import (
+	"strings"
+)
+
+// Triggers are the ways to address the bot besides starting a message with a mention of it,
+// prefixes and aliases are matched ignoring case.
+type Triggers struct {
+	// Prefixes address the bot when a message starts with one, e.g. "!" for `!dist-beta`
+	Prefixes []string
+	// Aliases are names addressing the bot when a message starts with one, optionally after "@"
+	// and followed by ":" or ",", e.g. "gobot" for `gobot: dist-beta`
+	Aliases []string
+	// MentionAnywhere addresses the bot by a mention in the middle of a message,
+	// the text after the mention is handled, or the one before it if there is nothing after
+	MentionAnywhere bool
+}
+
+// address strips what addresses the bot from text, and reports whether it's addressed.
+func (parser *MessageParser) address(text string) (string, bool) {
+	if strings.HasPrefix(text, parser.replyPrefix) {
+		text = text[len(parser.replyPrefix):]
+		if len(text) > 0 {
+			text = text[1:]
+		}
+		return text, true
+	}
+	for _, prefix := range parser.triggers.Prefixes {
+		if len(text) > len(prefix) && hasPrefixFold(text, prefix) {
+			return strings.TrimLeft(text[len(prefix):], " "), true
+		}
+	}
+	name := strings.TrimPrefix(text, "@")
+	for _, alias := range parser.triggers.Aliases {
+		if !hasPrefixFold(name, alias) {
+			continue
+		}
+		rest := name[len(alias):]
+		if len(rest) == 0 {
+			return "", true
+		}
+		if rest[0] == ' ' || rest[0] == ':' || rest[0] == ',' {
+			return strings.TrimLeft(rest, " :,"), true
+		}
+	}
+	if parser.triggers.MentionAnywhere {
+		if i := strings.Index(text, parser.replyPrefix); i >= 0 {
+			if after := strings.TrimSpace(text[i+len(parser.replyPrefix):]); len(after) > 0 {
+				return after, true
+			}
+			return strings.TrimSpace(text[:i]), true
+		}
+	}
+	return text, false
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,316 @@
+// Package gobottest runs a gobot.Bot over a fake slack to test handlers.
+//
+// The bot is the one made by gobot.New, it dispatches and replies as in production.
+// Tests talk to it by Say, which returns once the bot is done with the message, and assert on the replies:
+//
+//	bot := gobottest.NewBot(t)
+//	defer bot.Close()
+//	bot.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+//	bot.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+//	_ = bot.RegisterHandler(handler)
+//	replies, err := bot.Say("U123", "C123", "@gobot ps")
+package gobottest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+	"github.com/li-go/gobot/localrepo"
+)
+
+const (
+	BotUserID = "UGOBOT"
+	BotName   = "gobot"
+)
+
+var (
+	// SettleTimeout is how long Say and the like wait for the bot to be done with a message
+	SettleTimeout = 10 * time.Second
+)
+
+var (
+	ErrNotHandled     = errors.New("no handler handled the message")
+	ErrNotSettled     = errors.New("bot still busy")
+	ErrUnknownUser    = errors.New("unknown user")
+	ErrUnknownChannel = errors.New("unknown channel")
+	ErrNoSuchMessage  = errors.New("no such message")
+)
+
+// Bot is a bot made by gobot.New over a fake slack, whose users and channels are scripted by AddUser and AddChannel.
+// Questions asked by handlers are answered by the texts queued by Answer, or by the next message of the user.
+// The tasks of configurablecommand run only when RunTasks is called, as the scheduler isn't started in tests,
+// and they are stored into a temporary directory removed by Close.
+type Bot struct {
+	gobot.Bot
+	transport *transport
+	dir       string
+	prevPath  string
+
+	mutex   sync.Mutex
+	answers []string
+	said    map[gobot.MessageRef]gobot.MessageEvent
+	last    gobot.MessageRef
+	// handled and answered count the messages handled and the questions answered, errs are the errors of handlers
+	handled  int
+	answered int
+	errs     []error
+}
+
+// NewBot starts a bot with no user and no channel, opts are passed to gobot.New.
+// Messages are sent without rate limit unless opts set one.
+func NewBot(t testing.TB, opts ...gobot.Option) *Bot {
+	dir, err := ioutil.TempDir("", "gobottest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := newTransport()
+	logger := gobot.NewLogger(ioutil.Discard, gobot.LevelError, gobot.FormatLogfmt)
+	bot, err := gobot.New(tr, logger, append([]gobot.Option{gobot.OptionSendRate(time.Nanosecond, 100)}, opts...)...)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	b := &Bot{Bot: bot, transport: tr, dir: dir, said: make(map[gobot.MessageRef]gobot.MessageEvent)}
+	b.prevPath = localrepo.SetPath(filepath.Join(dir, "bot.sqlite"))
+	// outermost, so that it sees what the handlers return
+	b.Use(b.track)
+	go bot.Start()
+	return b
+}
+
+// Close shuts the bot down and removes the stored data.
+func (b *Bot) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), SettleTimeout)
+	defer cancel()
+	_ = b.Shutdown(ctx)
+	localrepo.SetPath(b.prevPath)
+	os.RemoveAll(b.dir)
+}
+
+func (b *Bot) AddUser(user gobot.User) {
+	b.transport.addUser(user)
+}
+
+func (b *Bot) AddChannel(channel gobot.Channel) {
+	b.transport.addChannel(channel)
+}
+
+// Answer queues answers to the questions handlers ask, they are said by the user of the message Say is saying.
+func (b *Bot) Answer(texts ...string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.answers = append(b.answers, texts...)
+}
+
+// Say sends text as the user in the channel, a leading @gobot mentions the bot.
+// It returns the messages sent until the bot is done with it, including the failures and the replies of the ai
+// to mentions no handler handles, and the first error of the handlers.
+// The error is ErrNotHandled if no handler handled it and it answered no question.
+func (b *Bot) Say(userID, channelID, text string) ([]gobot.OutgoingMessage, error) {
+	if err := b.check(userID, channelID); err != nil {
+		return nil, err
+	}
+	ev := gobot.MessageEvent{ChannelID: channelID, UserID: userID, Text: mention(text), TS: b.transport.newMessageTS()}
+	ref := gobot.MessageRef{ChannelID: channelID, TS: ev.TS}
+	b.mutex.Lock()
+	b.said[ref] = ev
+	b.last = ref
+	b.mutex.Unlock()
+	return b.handle(&ev)
+}
+
+// Edit edits the text of a message said by Say, the bot handles it again if it's edited within the edit window.
+func (b *Bot) Edit(ref gobot.MessageRef, text string) ([]gobot.OutgoingMessage, error) {
+	b.mutex.Lock()
+	ev, ok := b.said[ref]
+	if !ok {
+		b.mutex.Unlock()
+		return nil, ErrNoSuchMessage
+	}
+	ev.Edited, ev.PreviousText, ev.Text = true, ev.Text, mention(text)
+	b.said[ref] = ev
+	b.mutex.Unlock()
+	return b.handle(&ev)
+}
+
+// LastSaid returns the last message said by Say, e.g. to look up the reactions to it by its Ref.
+func (b *Bot) LastSaid() gobot.Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	ev := b.said[b.last]
+	return gobot.Message{ChannelID: ev.ChannelID, UserID: ev.UserID, Text: ev.Text, TS: ev.TS}
+}
+
+// Emit sends ev to the bot, and returns the messages sent until the bot is done with it.
+// The errors of event handlers are logged by the bot, the error tells whether the bot got done in time.
+func (b *Bot) Emit(ev gobot.Event) ([]gobot.OutgoingMessage, error) {
+	seq := b.transport.seq()
+	if err := b.emit(ev); err != nil {
+		return nil, err
+	}
+	err := b.settle()
+	return b.transport.sentSince(seq), err
+}
+
+// RunTasks runs the pending tasks of configurablecommand to completion, and returns the messages sent meanwhile.
+func (b *Bot) RunTasks() []gobot.OutgoingMessage {
+	seq := b.transport.seq()
+	configurablecommand.RunPendingTasks()
+	_ = b.settle()
+	return b.transport.sentSince(seq)
+}
+
+// Sent returns the messages sent so far and not deleted, as they are shown after updates.
+func (b *Bot) Sent() []gobot.OutgoingMessage {
+	return b.transport.sentSince(0)
+}
+
+// Reactions returns the reactions the bot has left on the message of ref.
+func (b *Bot) Reactions(ref gobot.MessageRef) []string {
+	return b.transport.reactionsOf(ref)
+}
+
+func (b *Bot) check(userID, channelID string) error {
+	if _, err := b.transport.GetChannel(channelID); err != nil {
+		return err
+	}
+	_, err := b.transport.GetUser(userID)
+	return err
+}
+
+// mention replaces a leading @gobot by a mention of the bot.
+func mention(text string) string {
+	if text == "@"+BotName || strings.HasPrefix(text, "@"+BotName+" ") {
+		return "<@" + BotUserID + ">" + text[len(BotName)+1:]
+	}
+	return text
+}
+
+// handle sends the message to the bot and answers the questions asked meanwhile by the queued answers.
+func (b *Bot) handle(ev *gobot.MessageEvent) ([]gobot.OutgoingMessage, error) {
+	seq := b.transport.seq()
+	b.mutex.Lock()
+	handled, answered, errs := b.handled, b.answered, len(b.errs)
+	b.mutex.Unlock()
+
+	err := b.emit(gobot.Event{Type: "message", Data: ev})
+	for err == nil {
+		if err = b.settle(); err != nil {
+			break
+		}
+		if b.Health().Questions == 0 {
+			break
+		}
+		answer, ok := b.nextAnswer()
+		if !ok {
+			break
+		}
+		err = b.emit(gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+			ChannelID: ev.ChannelID,
+			UserID:    ev.UserID,
+			Text:      answer,
+			TS:        b.transport.newMessageTS(),
+			ThreadTS:  ev.ThreadTS,
+		}})
+	}
+	msgs := b.transport.sentSince(seq)
+	if err != nil {
+		return msgs, err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.errs) > errs {
+		return msgs, b.errs[errs]
+	}
+	if b.handled == handled && b.answered == answered {
+		return msgs, ErrNotHandled
+	}
+	return msgs, nil
+}
+
+func (b *Bot) nextAnswer() (string, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if len(b.answers) == 0 {
+		return "", false
+	}
+	answer := b.answers[0]
+	b.answers = b.answers[1:]
+	return answer, true
+}
+
+// emit sends ev to the bot and returns once it's dispatched.
+// The bot is done dispatching an event once it receives the next one, so a message of the bot itself follows ev,
+// which the bot ignores and dispatches to no handler.
+func (b *Bot) emit(ev gobot.Event) error {
+	next := gobot.Event{Type: "message", Data: &gobot.MessageEvent{BotID: BotUserID}}
+	for _, ev := range []gobot.Event{ev, next} {
+		select {
+		case b.transport.events <- ev:
+		case <-time.After(SettleTimeout):
+			return fmt.Errorf("%w: %s event not received", ErrNotSettled, ev.Type)
+		}
+	}
+	return nil
+}
+
+// settle waits until every handler is done or waits for an answer, and the messages are sent.
+func (b *Bot) settle() error {
+	deadline := time.Now().Add(SettleTimeout)
+	for {
+		h := b.Health()
+		if h.Handlers <= h.Questions && h.Queued == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %+v", ErrNotSettled, h)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// track is the outermost middleware, it records what the handlers return and the answers they get.
+func (b *Bot) track(next gobot.HandlerFunc) gobot.HandlerFunc {
+	return func(bot gobot.Bot, msg gobot.Message) error {
+		b.mutex.Lock()
+		b.handled++
+		b.mutex.Unlock()
+		err := next(asker{Bot: bot, harness: b}, msg)
+		if err != nil {
+			b.mutex.Lock()
+			b.errs = append(b.errs, err)
+			b.mutex.Unlock()
+		}
+		return err
+	}
+}
+
+// asker counts the questions answered, so that a message answering one is handled.
+type asker struct {
+	gobot.Bot
+	harness *Bot
+}
+
+func (a asker) Ask(ctx context.Context, msg gobot.Message, question string) (gobot.Message, error) {
+	answer, err := a.Bot.Ask(ctx, msg, question)
+	if err == nil {
+		a.harness.mutex.Lock()
+		a.harness.answered++
+		a.harness.mutex.Unlock()
+	}
+	return answer, err
+}
+
+var _ gobot.Bot = (*Bot)(nil)
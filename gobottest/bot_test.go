@@ -0,0 +1,318 @@
+package gobottest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+)
+
+func newTestBot(t *testing.T) *Bot {
+	b := NewBot(t)
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	return b
+}
+
+// texts returns the texts of msgs, the first section of rich messages leaving out their fields, e.g. durations.
+func texts(msgs []gobot.OutgoingMessage) []string {
+	var tt []string
+	for _, msg := range msgs {
+		if msg.Rich != nil && len(msg.Rich.Sections) > 0 {
+			tt = append(tt, msg.Rich.Sections[0].Text)
+			continue
+		}
+		tt = append(tt, msg.Text)
+	}
+	return tt
+}
+
+func TestBot_Say(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	err := b.RegisterHandler(gobot.Handler{
+		Name:         "greet",
+		Help:         "greet",
+		NeedsMention: true,
+		Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
+			return msg.Text == "greet" || msg.Text == "fail"
+		},
+		Handle: func(bot gobot.Bot, msg gobot.Message) error {
+			if msg.Text == "fail" {
+				return errors.New("boom")
+			}
+			user, err := bot.LoadUser(msg.UserID)
+			if err != nil {
+				return err
+			}
+			_, err = bot.ReplyMessage("hello "+user, msg)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		userID  string
+		text    string
+		want    []string
+		wantErr error
+	}{
+		{userID: "U123", text: "@gobot greet", want: []string{"hello @alice"}},
+		{userID: "U123", text: "greet", wantErr: ErrNotHandled},
+		{userID: "U123", text: "@gobot fail", want: []string{"<@U123> *failed* - `fail` :see_no_evil:"}, wantErr: errors.New("boom")},
+		{userID: "U999", text: "@gobot greet", wantErr: errors.New("unknown user: U999")},
+	}
+	for _, tt := range tests {
+		got, err := b.Say(tt.userID, "C123", tt.text)
+		if !reflect.DeepEqual(err, tt.wantErr) && (err == nil || tt.wantErr == nil || err.Error() != tt.wantErr.Error()) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(texts(got), tt.want) {
+			t.Errorf("Say(%q) = %q, want %q", tt.text, texts(got), tt.want)
+		}
+	}
+}
+
+func TestBot_RunTasks(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	commands := []configurablecommand.Command{
+		{Name: "deploy", Command: "deploy() { echo post_slack_begin; echo deployed $2; echo post_slack_end; }; deploy", ParamNames: []string{"branch"}, RequiredParamNames: []string{"branch"}},
+		{Name: "broken", Command: "exit 1"},
+	}
+	for _, c := range commands {
+		if err := b.RegisterHandler(c.Handler()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.Answer("main")
+	got, err := b.Say("U123", "C123", "@gobot deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"<@U123> please input `--branch`:"}; !reflect.DeepEqual(texts(got), want) {
+		t.Errorf("Say(deploy) = %q, want %q", texts(got), want)
+	}
+	deploy := b.LastSaid().Ref()
+	if _, err := b.Say("U123", "C123", "@gobot broken"); err != nil {
+		t.Fatal(err)
+	}
+	broken := b.LastSaid().Ref()
+
+	got = b.RunTasks()
+	want := []string{
+		"deployed main",
+		"<@U123> *succeeded* - `deploy --branch \"main\"` :open_mouth:",
+		"<@U123> *failed* - `broken` :see_no_evil:",
+	}
+	if !reflect.DeepEqual(texts(got), want) {
+		t.Errorf("RunTasks() = %q, want %q", texts(got), want)
+	}
+	if got, want := b.Reactions(deploy), []string{"white_check_mark"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reactions of deploy = %v, want %v", got, want)
+	}
+	if got, want := b.Reactions(broken), []string{"x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reactions of broken = %v, want %v", got, want)
+	}
+	if got := b.RunTasks(); len(got) > 0 {
+		t.Errorf("RunTasks() = %q, want nothing", texts(got))
+	}
+}
+
+func TestBot_conversation(t *testing.T) {
+	b := NewBot(t, gobot.OptionEditWindow(time.Minute))
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	err := b.RegisterHandler(gobot.Handler{
+		Name:         "greet",
+		Help:         "greet",
+		NeedsMention: true,
+		Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
+			return msg.Text == "greet"
+		},
+		Handle: func(bot gobot.Bot, msg gobot.Message) error {
+			answer, err := bot.Ask(context.Background(), msg, "who?")
+			if err != nil {
+				return err
+			}
+			_, err = bot.SendMessage("hello "+answer.Text, msg.ChannelID)
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// not answered by Answer, the question waits for the next message of the user
+	got, err := b.Say("U123", "C123", "@gobot greet")
+	if want := []string{"<@U123> who?"}; err != nil || !reflect.DeepEqual(texts(got), want) {
+		t.Errorf("Say(greet) = %q, %v, want %q", texts(got), err, want)
+	}
+	greet := b.LastSaid().Ref()
+	got, err = b.Say("U123", "C123", "bob")
+	if want := []string{"hello bob"}; err != nil || !reflect.DeepEqual(texts(got), want) {
+		t.Errorf("Say(bob) = %q, %v, want %q", texts(got), err, want)
+	}
+
+	b.Answer("carol")
+	got, err = b.Edit(greet, "@gobot  greet")
+	if want := []string{"<@U123> who?", "hello carol"}; err != nil || !reflect.DeepEqual(texts(got), want) {
+		t.Errorf("Edit(greet) = %q, %v, want %q", texts(got), err, want)
+	}
+
+	// mentions no handler handles are answered by the ai
+	got, err = b.Say("U123", "C123", "@gobot hello")
+	if err != ErrNotHandled || len(got) != 1 || !strings.HasPrefix(got[0].Text, "<@"+BotUserID+"> hello? ") {
+		t.Errorf("Say(hello) = %q, %v, want an answer of the ai, %v", texts(got), err, ErrNotHandled)
+	}
+
+	if got, want := b.Help(gobot.LangEnglish), "```\navailable commands:\n  * @gobot greet\n```"; got != want {
+		t.Errorf("Help() = %q, want %q", got, want)
+	}
+}
+
+func TestBot_RunTasks_entities(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	c := configurablecommand.Command{Name: "args", Command: `args() { echo post_slack_begin; echo "$#:$2"; echo post_slack_end; }; args`, ParamNames: []string{"branch"}}
+	if err := b.RegisterHandler(c.Handler()); err != nil {
+		t.Fatal(err)
+	}
+
+	// slack escapes & in the text of messages
+	if _, err := b.Say("U123", "C123", "@gobot args --branch feature&amp;fix"); err != nil {
+		t.Fatal(err)
+	}
+	got := texts(b.RunTasks())
+	if len(got) == 0 || got[0] != "2:feature&fix" {
+		t.Errorf("RunTasks() = %q, want the script to get %q as one argument", got, "feature&fix")
+	}
+}
+
+func TestBot_RunTasks_kill(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	c := configurablecommand.Command{Name: "slow", Command: "echo post_slack_begin; echo started; echo post_slack_end; sleep 5"}
+	if err := b.RegisterHandler(c.Handler()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Say("U123", "C123", "@gobot slow"); err != nil {
+		t.Fatal(err)
+	}
+	ref := b.LastSaid().Ref()
+	var task *configurablecommand.Task
+	for _, tt := range configurablecommand.GetTasks(b.Workspace()) {
+		if tt.Msg.Ref() == ref {
+			task, _ = configurablecommand.FindTask(b.Workspace(), tt.ID)
+		}
+	}
+	if task == nil {
+		t.Fatal("task of slow not found")
+	}
+
+	start := time.Now()
+	done := make(chan []gobot.OutgoingMessage)
+	go func() {
+		done <- b.RunTasks()
+	}()
+	// the command posts once it's running
+	for !reflect.DeepEqual(texts(b.Sent()), []string{"started"}) {
+		if time.Since(start) > SettleTimeout {
+			t.Fatalf("Sent() = %q, want the command running", texts(b.Sent()))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := task.Kill("U123"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunTasks() not returned, want the killed command stopped")
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("killed command ran for %v, want it stopped early", d)
+	}
+	if got := task.Status(); got != configurablecommand.Killed {
+		t.Errorf("Status() = %v, want %v", got, configurablecommand.Killed)
+	}
+}
+
+func TestBot_Edit_task(t *testing.T) {
+	b := NewBot(t, gobot.OptionEditWindow(time.Minute))
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	c := configurablecommand.Command{Name: "deploy", Command: "deploy() { echo post_slack_begin; echo deployed $2; echo post_slack_end; }; deploy", ParamNames: []string{"branch"}}
+	if err := b.RegisterHandler(c.Handler()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Say("U123", "C123", "@gobot deploy --branch foo"); err != nil {
+		t.Fatal(err)
+	}
+	ref := b.LastSaid().Ref()
+	if _, err := b.Edit(ref, "@gobot deploy --branch bar"); err != nil {
+		t.Fatal(err)
+	}
+	var pending []string
+	for _, task := range configurablecommand.GetTasks(b.Workspace()) {
+		if task.Msg.Ref() == ref && task.Status() == configurablecommand.Pending {
+			pending = append(pending, task.Msg.Text)
+		}
+	}
+	if want := []string{"deploy --branch bar"}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("pending tasks = %q, want %q", pending, want)
+	}
+
+	got := texts(b.RunTasks())
+	if len(got) == 0 || got[0] != "deployed bar" {
+		t.Errorf("RunTasks() = %q, want only the edited command to run", got)
+	}
+}
+
+func TestBot_Emit(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	seen := make(chan gobot.Event, 10)
+	err := b.RegisterEventHandler(gobot.EventHandler{
+		Name: "all",
+		Handleable: func(bot gobot.Bot, ev gobot.Event) bool {
+			return true
+		},
+		Handle: func(bot gobot.Bot, ev gobot.Event) error {
+			seen <- ev
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// messages aren't events of event handlers
+	if _, err := b.Say("U123", "C123", "hello"); err != ErrNotHandled {
+		t.Fatalf("Say(hello) error = %v, want %v", err, ErrNotHandled)
+	}
+	ev := gobot.Event{Type: "channel_created", Data: &gobot.ChannelCreatedEvent{ChannelID: "C456", Name: "random"}}
+	if _, err := b.Emit(ev); err != nil {
+		t.Fatal(err)
+	}
+	close(seen)
+	var got []gobot.Event
+	for ev := range seen {
+		got = append(got, ev)
+	}
+	if want := []gobot.Event{ev}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events handled = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,214 @@
+package gobottest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+// sent is a message the bot has sent, seq orders the messages including the deleted ones.
+type sent struct {
+	seq     int
+	ref     gobot.MessageRef
+	msg     gobot.OutgoingMessage
+	deleted bool
+}
+
+// transport is a fake slack, the users and channels are scripted and what the bot sends is recorded.
+// The events are the ones emitted by the Bot.
+type transport struct {
+	events chan gobot.Event
+
+	mutex     sync.Mutex
+	users     map[string]gobot.User
+	channels  map[string]gobot.Channel
+	sent      []*sent
+	reactions map[gobot.MessageRef][]string
+	ts        int
+}
+
+func newTransport() *transport {
+	return &transport{
+		events:    make(chan gobot.Event),
+		users:     make(map[string]gobot.User),
+		channels:  make(map[string]gobot.Channel),
+		reactions: make(map[gobot.MessageRef][]string),
+	}
+}
+
+func (t *transport) Connect() (*gobot.Identity, error) {
+	return &gobot.Identity{UserID: BotUserID, UserName: BotName}, nil
+}
+
+func (t *transport) Run() {}
+
+func (t *transport) Disconnect() error {
+	return nil
+}
+
+func (t *transport) IncomingEvents() <-chan gobot.Event {
+	return t.events
+}
+
+// SendMessage records msg, the text of a rich message is its plain text unless given.
+func (t *transport) SendMessage(msg gobot.OutgoingMessage) (gobot.MessageRef, error) {
+	if msg.Rich != nil && len(msg.Text) == 0 {
+		msg.Text = msg.Rich.PlainText()
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if _, ok := t.channels[msg.ChannelID]; !ok {
+		return gobot.MessageRef{}, fmt.Errorf("%w: %s", ErrUnknownChannel, msg.ChannelID)
+	}
+	ref := gobot.MessageRef{ChannelID: msg.ChannelID, TS: t.nextTS()}
+	t.sent = append(t.sent, &sent{seq: len(t.sent), ref: ref, msg: msg})
+	return ref, nil
+}
+
+// UploadFile records the file as a message of its content.
+func (t *transport) UploadFile(file gobot.FileUpload) (gobot.MessageRef, error) {
+	return t.SendMessage(gobot.OutgoingMessage{ChannelID: file.ChannelID, ThreadTS: file.ThreadTS, Text: file.Content})
+}
+
+func (t *transport) UpdateMessage(ref gobot.MessageRef, msg gobot.OutgoingMessage) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	s, err := t.find(ref)
+	if err != nil {
+		return err
+	}
+	msg.ChannelID, msg.ThreadTS = s.msg.ChannelID, s.msg.ThreadTS
+	s.msg = msg
+	return nil
+}
+
+func (t *transport) DeleteMessage(ref gobot.MessageRef) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	s, err := t.find(ref)
+	if err != nil {
+		return err
+	}
+	s.deleted = true
+	return nil
+}
+
+func (t *transport) AddReaction(name string, ref gobot.MessageRef) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, r := range t.reactions[ref] {
+		if r == name {
+			return nil
+		}
+	}
+	t.reactions[ref] = append(t.reactions[ref], name)
+	return nil
+}
+
+func (t *transport) RemoveReaction(name string, ref gobot.MessageRef) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var reactions []string
+	for _, r := range t.reactions[ref] {
+		if r != name {
+			reactions = append(reactions, r)
+		}
+	}
+	t.reactions[ref] = reactions
+	return nil
+}
+
+func (t *transport) GetUser(userID string) (*gobot.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	u, ok := t.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownUser, userID)
+	}
+	return &u, nil
+}
+
+func (t *transport) GetChannel(channelID string) (*gobot.Channel, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	c, ok := t.channels[channelID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channelID)
+	}
+	return &c, nil
+}
+
+func (t *transport) ListChannels() ([]gobot.Channel, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var channels []gobot.Channel
+	for _, c := range t.channels {
+		channels = append(channels, c)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].ID < channels[j].ID
+	})
+	return channels, nil
+}
+
+func (t *transport) addUser(user gobot.User) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.users[user.ID] = user
+}
+
+func (t *transport) addChannel(channel gobot.Channel) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.channels[channel.ID] = channel
+}
+
+// newMessageTS returns the timestamp of a message said to the bot, timestamps are shared with the sent messages.
+func (t *transport) newMessageTS() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.nextTS()
+}
+
+func (t *transport) nextTS() string {
+	t.ts++
+	return strconv.Itoa(t.ts)
+}
+
+func (t *transport) seq() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.sent)
+}
+
+// sentSince returns the messages sent from seq on and not deleted, as they are shown after updates.
+func (t *transport) sentSince(seq int) []gobot.OutgoingMessage {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var msgs []gobot.OutgoingMessage
+	for _, s := range t.sent {
+		if s.seq < seq || s.deleted {
+			continue
+		}
+		msgs = append(msgs, s.msg)
+	}
+	return msgs
+}
+
+func (t *transport) reactionsOf(ref gobot.MessageRef) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]string(nil), t.reactions[ref]...)
+}
+
+func (t *transport) find(ref gobot.MessageRef) (*sent, error) {
+	for _, s := range t.sent {
+		if s.ref == ref && !s.deleted {
+			return s, nil
+		}
+	}
+	return nil, ErrNoSuchMessage
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+)
+
+// cancelHandler cancels the pending tasks of deleted messages.
+var cancelHandler = gobot.EventHandler{
+	Name: "cancel",
+	Handleable: func(bot gobot.Bot, ev gobot.Event) bool {
+		_, ok := ev.Data.(*gobot.MessageDeletedEvent)
+		return ok
+	},
+	Handle: func(bot gobot.Bot, ev gobot.Event) error {
+		ref := ev.Data.(*gobot.MessageDeletedEvent).Ref()
+		for _, task := range configurablecommand.CancelTasks(bot.Workspace(), ref) {
+			bot.SendMessage(task.Msg.T("cancel.deleted", task.Msg.Text), ref.ChannelID)
+		}
+		return nil
+	},
+}
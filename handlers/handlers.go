@@ -2,6 +2,10 @@ package handlers
 
 import "github.com/li-go/gobot/gobot"
 
+const (
+	reactionDone = "white_check_mark"
+)
+
 var (
 	All = []gobot.Handler{
 		helpHandler,
@@ -9,5 +13,18 @@ var (
 		lookupHandler,
 		psHandler,
 		killHandler,
+		langHandler,
+	}
+
+	Events = []gobot.EventHandler{
+		cancelHandler,
 	}
 )
+
+// confirm acknowledges msg with a reaction, or with text if it can't be reacted to, e.g. a slash command.
+func confirm(bot gobot.Bot, msg gobot.Message, text string) {
+	if len(msg.TS) > 0 && bot.AddReaction(reactionDone, msg.Ref()) == nil {
+		return
+	}
+	bot.SendMessage(text, msg.ChannelID)
+}
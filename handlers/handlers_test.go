@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+	"github.com/li-go/gobot/gobottest"
+)
+
+func newTestBot(t *testing.T) *gobottest.Bot {
+	b := gobottest.NewBot(t, gobot.OptionWorkspace("handlers-test"))
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	for _, h := range All {
+		if err := b.RegisterHandler(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestHandlers(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	if err := b.RegisterHandler(configurablecommand.Command{Name: "sleep", Command: "sleep 0"}.Handler()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text    string
+		tasks   bool
+		want    []string
+		wantErr string
+	}{
+		{text: "@gobot lang", want: []string{"your language is en (available: en, ja)"}},
+		{text: "@gobot lang fr", want: []string{"<@U123> *failed* - `lang fr` :see_no_evil:"}, wantErr: "unknown language fr (available: en, ja)"},
+		{text: "@gobot sleep"},
+		{text: "@gobot ps", want: []string{"Latest commands:", "*1.* `sleep`"}},
+		{text: "@gobot kill 1", want: []string{"Latest commands:", "*1.* `sleep`"}},
+		{text: "@gobot kill 2", want: []string{"<@U123> *failed* - `kill 2` :see_no_evil:"}, wantErr: configurablecommand.ErrTaskNotFound.Error()},
+		// killed tasks are not run
+		{text: "@gobot ps", tasks: true, want: []string{"Latest commands:", "*1.* `sleep`"}},
+	}
+	for _, tt := range tests {
+		got, err := b.Say("U123", "C123", tt.text)
+		if (err != nil || len(tt.wantErr) > 0) && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		if tt.tasks {
+			got = append(got, b.RunTasks()...)
+		}
+		var texts []string
+		for _, msg := range got {
+			if msg.Rich == nil {
+				texts = append(texts, msg.Text)
+				continue
+			}
+			for _, s := range msg.Rich.Sections {
+				texts = append(texts, s.Title+s.Text)
+			}
+		}
+		if !reflect.DeepEqual(texts, tt.want) {
+			t.Errorf("Say(%q) = %q, want %q", tt.text, texts, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U456", Name: "bob", DisplayName: "bobby"})
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "lookup: <@U456>", want: []string{"```\n{\n  \"ID\": \"U456\",\n  \"Name\": \"bob\",\n  \"DisplayName\": \"bobby\",\n  \"StatusText\": \"\",\n  \"StatusEmoji\": \"\"\n}\n```"}},
+		{text: "lookup: <@U456|bob>", want: []string{"```\n{\n  \"ID\": \"U456\",\n  \"Name\": \"bob\",\n  \"DisplayName\": \"bobby\",\n  \"StatusText\": \"\",\n  \"StatusEmoji\": \"\"\n}\n```"}},
+	}
+	for _, tt := range tests {
+		got, err := b.Say("U123", "C123", tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		for _, msg := range got {
+			texts = append(texts, msg.Text)
+		}
+		if !reflect.DeepEqual(texts, tt.want) {
+			t.Errorf("Say(%q) = %q, want %q", tt.text, texts, tt.want)
+		}
+	}
+	if _, err := b.Say("U123", "C123", "lookup: @U456"); err != gobottest.ErrNotHandled {
+		t.Errorf("Say(lookup: @U456) error = %v, want %v", err, gobottest.ErrNotHandled)
+	}
+}
+
+func TestHandlers_ignoreCase(t *testing.T) {
+	b := gobottest.NewBot(t, gobot.OptionWorkspace("handlers-case-test"), gobot.OptionTriggers(gobot.Triggers{Prefixes: []string{"!"}}))
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	for _, h := range All {
+		if err := b.RegisterHandler(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		text    string
+		want    string
+		wantErr string
+	}{
+		{text: "!PS", want: "Latest commands:"},
+		{text: "!Help?", want: "```\navailable commands:"},
+		{text: "!LANG", want: "your language is en (available: en, ja)"},
+		{text: "!Kill 99", want: "<@U123> *failed* - `Kill 99` :see_no_evil:", wantErr: configurablecommand.ErrTaskNotFound.Error()},
+		{text: "Lunch", want: "```\n  * " + lunchHelp + "\n```"},
+	}
+	for _, tt := range tests {
+		got, err := b.Say("U123", "C123", tt.text)
+		if (err != nil || len(tt.wantErr) > 0) && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		if len(got) == 0 {
+			t.Errorf("Say(%q) sent nothing, want %q", tt.text, tt.want)
+			continue
+		}
+		text := got[0].Text
+		if got[0].Rich != nil {
+			text = got[0].Rich.Sections[0].Title + got[0].Rich.Sections[0].Text
+		}
+		if !strings.HasPrefix(text, tt.want) {
+			t.Errorf("Say(%q) = %q, want prefix %q", tt.text, text, tt.want)
+		}
+	}
+}
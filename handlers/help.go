@@ -1,15 +1,19 @@
 package handlers
 
-import "github.com/li-go/gobot/gobot"
+import (
+	"strings"
+
+	"github.com/li-go/gobot/gobot"
+)
 
 var helpHandler = gobot.Handler{
 	Name: "help",
 	Help: "help? - print help information",
 	Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
-		return msg.Text == "help?"
+		return strings.EqualFold(msg.Text, "help?")
 	},
 	Handle: func(bot gobot.Bot, msg gobot.Message) error {
-		bot.SendMessage(bot.Help(), msg.ChannelID)
+		bot.SendMessage(bot.Help(msg.Lang), msg.ChannelID)
 		return nil
 	},
 }
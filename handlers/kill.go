@@ -9,7 +9,7 @@ import (
 )
 
 var (
-	killPattern = regexp.MustCompile(`^kill (\d+)$`)
+	killPattern = regexp.MustCompile(`(?i)^kill (\d+)$`)
 )
 
 var killHandler = gobot.Handler{
@@ -21,13 +21,17 @@ var killHandler = gobot.Handler{
 	},
 	Handle: func(bot gobot.Bot, msg gobot.Message) error {
 		id, _ := strconv.Atoi(killPattern.FindStringSubmatch(msg.Text)[1])
-		task, err := configurablecommand.FindTask(id)
+		task, err := configurablecommand.FindTask(msg.Workspace, id)
 		if err != nil {
 			return err
 		}
 		if err := task.Kill(msg.UserID); err != nil {
 			return err
 		}
+		// there is nothing to react to when killed by a button
+		if len(msg.TS) > 0 {
+			_ = bot.AddReaction(reactionDone, msg.Ref())
+		}
 		return psHandler.Handle(bot, msg)
 	},
 }
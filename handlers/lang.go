@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/li-go/gobot/gobot"
+	"github.com/li-go/gobot/localrepo"
+)
+
+var (
+	langPattern = regexp.MustCompile(`(?i)^lang(?: (\S+))?$`)
+)
+
+var langHandler = gobot.Handler{
+	Name:         "lang",
+	Help:         "lang [en|ja] - show or select the language the bot replies to you in",
+	NeedsMention: true,
+	Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
+		return langPattern.MatchString(msg.Text)
+	},
+	Handle: func(bot gobot.Bot, msg gobot.Message) error {
+		available := strings.Join(gobot.Languages(), ", ")
+		lang := strings.ToLower(langPattern.FindStringSubmatch(msg.Text)[1])
+		if len(lang) == 0 {
+			bot.SendMessage(msg.T("lang.current", msg.Lang, available), msg.ChannelID)
+			return nil
+		}
+		if !gobot.IsLanguage(lang) {
+			return gobot.NewError("lang.unknown", lang, available)
+		}
+
+		store, err := newLangStore()
+		if err != nil {
+			return fmtStorageErr(err)
+		}
+		defer store.Close()
+		if err := store.Set(UserLanguage{Workspace: bot.Workspace(), UserID: msg.UserID, Lang: lang}); err != nil {
+			return fmtStorageErr(err)
+		}
+		bot.SetUserLanguage(msg.UserID, lang)
+		confirm(bot, msg, gobot.Translate(lang, "lang.selected"))
+		return nil
+	},
+}
+
+// LoadUserLanguages restores the languages users of the workspace of bot have selected by `lang`.
+func LoadUserLanguages(bot gobot.Bot) error {
+	store, err := newLangStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	languages, err := store.All(bot.Workspace())
+	if err != nil {
+		return err
+	}
+	for _, l := range languages {
+		bot.SetUserLanguage(l.UserID, l.Lang)
+	}
+	return nil
+}
+
+// UserLanguage is the language a user has selected in a workspace.
+type UserLanguage struct {
+	Workspace string `db:"workspace" gorm:"primary_key"`
+	UserID    string `db:"user_id" gorm:"primary_key"`
+	Lang      string `db:"lang" gorm:"not null"`
+}
+
+type langStore struct {
+	repo localrepo.Repository
+}
+
+func newLangStore() (*langStore, error) {
+	repo, err := localrepo.New()
+	if err != nil {
+		return nil, err
+	}
+	if err = repo.Migrate(UserLanguage{}); err != nil {
+		return nil, err
+	}
+	return &langStore{repo: repo}, nil
+}
+
+func (store *langStore) Close() error {
+	return store.repo.Close()
+}
+
+// Set replaces the language the user has selected.
+func (store *langStore) Set(l UserLanguage) error {
+	if err := store.repo.Del(&UserLanguage{Workspace: l.Workspace, UserID: l.UserID}); err != nil {
+		return err
+	}
+	return store.repo.Put(&l)
+}
+
+func (store *langStore) All(workspace string) ([]UserLanguage, error) {
+	var all []UserLanguage
+	if err := store.repo.GetAll(UserLanguage{Workspace: workspace}, &all); err != nil {
+		return nil, err
+	}
+	// the zero workspace matches every workspace
+	var languages []UserLanguage
+	for _, l := range all {
+		if l.Workspace == workspace {
+			languages = append(languages, l)
+		}
+	}
+	return languages, nil
+}
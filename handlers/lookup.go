@@ -8,28 +8,22 @@ import (
 )
 
 var (
-	lookupPattern = regexp.MustCompile(`^lookup: <@(\w+)>$`)
+	lookupPattern = regexp.MustCompile(`(?i)^lookup: @\S+$`)
 )
 
 var lookupHandler = gobot.Handler{
 	Name: "lookup",
 	Help: "lookup: @someone - show information of @someone",
 	Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
-		return lookupPattern.MatchString(msg.Text)
+		return lookupPattern.MatchString(msg.Text) && len(msg.Users) == 1
 	},
 	Handle: func(bot gobot.Bot, msg gobot.Message) error {
-		userID := lookupPattern.FindStringSubmatch(msg.Text)[1]
-		user, err := bot.GetRTM().GetUserInfo(userID)
+		user, err := bot.GetTransport().GetUser(msg.Users[0].ID)
 		if err != nil {
 			return err
 		}
 
-		simpleUser := struct{ ID, Name, DisplayName string }{
-			ID:          user.ID,
-			Name:        user.Name,
-			DisplayName: user.Profile.DisplayName,
-		}
-		buf, err := json.MarshalIndent(simpleUser, "", "  ")
+		buf, err := json.MarshalIndent(user, "", "  ")
 		if err != nil {
 			return err
 		}
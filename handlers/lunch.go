@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"errors"
-	"fmt"
 	"math/rand"
 	"regexp"
 	"strconv"
@@ -15,15 +14,18 @@ import (
 var (
 	lunchHelp = "lunch [add|rm|ls|gacha]"
 
-	lunchPattern      = regexp.MustCompile(`^lunch$`)
-	lunchAddPattern   = regexp.MustCompile(`^lunch add (.+)$`)
-	lunchRmPattern    = regexp.MustCompile(`^lunch rm (.+)$`)
-	lunchLsPattern    = regexp.MustCompile(`^lunch ls$`)
-	lunchGachaPattern = regexp.MustCompile(`^lunch gacha$`)
+	// commands are matched ignoring case like the triggers, names of restaurants as given
+	lunchPattern      = regexp.MustCompile(`(?i)^lunch$`)
+	lunchAddPattern   = regexp.MustCompile(`(?i)^lunch add (.+)$`)
+	lunchRmPattern    = regexp.MustCompile(`(?i)^lunch rm (.+)$`)
+	lunchLsPattern    = regexp.MustCompile(`(?i)^lunch ls$`)
+	lunchGachaPattern = regexp.MustCompile(`(?i)^lunch gacha$`)
 
 	fmtStorageErr = func(err error) error {
-		return fmt.Errorf("storage error: %v", err)
+		return gobot.NewError("storage.error", err)
 	}
+
+	errNoRestaurant = errors.New("no restaurant yet")
 )
 
 var lunchHandler = gobot.Handler{
@@ -58,24 +60,24 @@ var lunchHandler = gobot.Handler{
 			name := lunchAddPattern.FindStringSubmatch(msg.Text)[1]
 			r := Restaurant{Name: name}
 			if store.Exists(r) {
-				return errors.New("restaurant already exists")
+				return gobot.NewError("lunch.exists")
 			}
 			if err := store.Add(r); err != nil {
 				return fmtStorageErr(err)
 			}
-			bot.SendMessage("new restaurant added!", msg.ChannelID)
+			confirm(bot, msg, msg.T("lunch.added"))
 			return nil
 		}
 		if lunchRmPattern.MatchString(msg.Text) {
 			name := lunchRmPattern.FindStringSubmatch(msg.Text)[1]
 			r := Restaurant{Name: name}
 			if !store.Exists(r) {
-				return errors.New("restaurant doesn't exist")
+				return gobot.NewError("lunch.missing")
 			}
 			if err := store.Remove(r); err != nil {
 				return fmtStorageErr(err)
 			}
-			bot.SendMessage("restaurant removed!", msg.ChannelID)
+			confirm(bot, msg, msg.T("lunch.removed"))
 			return nil
 		}
 		if lunchLsPattern.MatchString(msg.Text) {
@@ -83,7 +85,7 @@ var lunchHandler = gobot.Handler{
 			if err != nil {
 				return fmtStorageErr(err)
 			}
-			s := "```\nRestaurants:\n"
+			s := "```\n" + msg.T("lunch.list") + "\n"
 			for i, r := range restaurants {
 				s += "  " + strconv.Itoa(i+1) + ". " + r.Name + "\n"
 			}
@@ -93,10 +95,13 @@ var lunchHandler = gobot.Handler{
 		}
 		if lunchGachaPattern.MatchString(msg.Text) {
 			restaurant, err := store.One()
+			if err == errNoRestaurant {
+				return gobot.NewError("lunch.empty")
+			}
 			if err != nil {
-				return fmtStorageErr(err)
+				return err
 			}
-			bot.SendMessage("Let's GO *"+restaurant.Name+"* today! :rice:", msg.ChannelID)
+			bot.SendMessage(msg.T("lunch.gacha", restaurant.Name), msg.ChannelID)
 			return nil
 		}
 		return nil
@@ -145,6 +150,9 @@ func (store *lunchStore) One() (*Restaurant, error) {
 	if err := store.repo.GetAll(Restaurant{}, &rr); err != nil {
 		return nil, err
 	}
+	if len(rr) == 0 {
+		return nil, errNoRestaurant
+	}
 	rand.Seed(time.Now().UnixNano())
 	return &rr[rand.Intn(len(rr))], nil
 }
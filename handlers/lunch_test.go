@@ -2,56 +2,48 @@ package handlers
 
 import (
 	"errors"
+	"reflect"
 	"testing"
-
-	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/mock"
-)
-
-type mockRepo struct {
-	mock.Mock
-}
-
-func (mockRepo) Migrate(data ...interface{}) error {
-	panic("implement me")
-}
-
-func (m mockRepo) Put(data interface{}) error {
-	ret := m.Called(data)
-	return ret.Error(0)
-}
-
-func (mockRepo) Del(cond interface{}) error {
-	panic("implement me")
-}
-
-func (mockRepo) GetOne(cond interface{}, data interface{}) error {
-	panic("implement me")
-}
-
-func (mockRepo) GetAll(cond interface{}, data interface{}) error {
-	panic("implement me")
-}
-
-func (mockRepo) Close() error {
-	panic("implement me")
-}
-
-var (
-	_mock = &mockRepo{}
 )
 
-func TestLunchAi_Add(t *testing.T) {
-	ai, _ := newLunchStore()
-	ai.repo = _mock
-
-	r := Restaurant{Name: "restaurant1"}
-	err := errors.New("err1")
-	_call := _mock.On("Put", r)
-
-	_call.Return(nil)
-	assert.Equal(t, ai.Add(r), nil)
-
-	_call.Return(err)
-	assert.Equal(t, ai.Add(r), err)
+func TestLunch(t *testing.T) {
+	b := newTestBot(t)
+	defer b.Close()
+
+	tests := []struct {
+		text          string
+		want          []string
+		wantReactions []string
+		wantErr       error
+	}{
+		{text: "lunch", want: []string{"```\n  * " + lunchHelp + "\n```"}},
+		{text: "lunch gacha", want: []string{"<@U123> *failed* - `lunch gacha` :see_no_evil:"}, wantErr: errors.New("no restaurant yet, add one by `lunch add <name>`")},
+		{text: "lunch add ramen", wantReactions: []string{reactionDone}},
+		{text: "lunch add ramen", want: []string{"<@U123> *failed* - `lunch add ramen` :see_no_evil:"}, wantErr: errors.New("restaurant already exists")},
+		{text: "lunch ls", want: []string{"```\nRestaurants:\n  1. ramen\n```"}},
+		{text: "lunch gacha", want: []string{"Let's GO *ramen* today! :rice:"}},
+		{text: "lunch rm ramen", wantReactions: []string{reactionDone}},
+		{text: "lunch rm ramen", want: []string{"<@U123> *failed* - `lunch rm ramen` :see_no_evil:"}, wantErr: errors.New("restaurant doesn't exist")},
+		{text: "lunch ls", want: []string{"```\nRestaurants:\n```"}},
+	}
+	for _, tt := range tests {
+		got, err := b.Say("U123", "C123", tt.text)
+		if !reflect.DeepEqual(err, tt.wantErr) && (err == nil || tt.wantErr == nil || err.Error() != tt.wantErr.Error()) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		var texts []string
+		for _, msg := range got {
+			if msg.Rich != nil && len(msg.Rich.Sections) > 0 {
+				texts = append(texts, msg.Rich.Sections[0].Text)
+				continue
+			}
+			texts = append(texts, msg.Text)
+		}
+		if !reflect.DeepEqual(texts, tt.want) {
+			t.Errorf("Say(%q) = %q, want %q", tt.text, texts, tt.want)
+		}
+		if got := b.Reactions(b.LastSaid().Ref()); !reflect.DeepEqual(got, tt.wantReactions) {
+			t.Errorf("reactions of %q = %v, want %v", tt.text, got, tt.wantReactions)
+		}
+	}
 }
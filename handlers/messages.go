@@ -0,0 +1,52 @@
+package handlers
+
+import "github.com/li-go/gobot/gobot"
+
+func init() {
+	gobot.RegisterMessages(gobot.LangEnglish, gobot.Bundle{
+		"lunch.added":   "new restaurant added!",
+		"lunch.removed": "restaurant removed!",
+		"lunch.exists":  "restaurant already exists",
+		"lunch.missing": "restaurant doesn't exist",
+		"lunch.empty":   "no restaurant yet, add one by `lunch add <name>`",
+		"lunch.list":    "Restaurants:",
+		"lunch.gacha":   "Let's GO *%s* today! :rice:",
+
+		"ps.title":     "Latest commands:",
+		"ps.anonymous": "anonymous",
+		"field.status": "Status",
+		"field.user":   "User",
+		"field.time":   "Time",
+
+		"cancel.deleted": "cancelled - `%s` (the message was deleted)",
+
+		"lang.current":  "your language is %s (available: %s)",
+		"lang.unknown":  "unknown language %s (available: %s)",
+		"lang.selected": "language set to English",
+
+		"storage.error": "storage error: %v",
+	})
+	gobot.RegisterMessages(gobot.LangJapanese, gobot.Bundle{
+		"lunch.added":   "お店を追加しました!",
+		"lunch.removed": "お店を削除しました!",
+		"lunch.exists":  "そのお店はもう登録されています",
+		"lunch.missing": "そのお店は登録されていません",
+		"lunch.empty":   "お店がまだありません、`lunch add <name>` で追加してください",
+		"lunch.list":    "お店一覧:",
+		"lunch.gacha":   "今日は *%s* に行こう! :rice:",
+
+		"ps.title":     "最近のコマンド:",
+		"ps.anonymous": "匿名",
+		"field.status": "状態",
+		"field.user":   "ユーザー",
+		"field.time":   "時間",
+
+		"cancel.deleted": "キャンセルしました - `%s` (メッセージが削除されました)",
+
+		"lang.current":  "あなたの言語は %s です (選べる言語: %s)",
+		"lang.unknown":  "%s は未対応の言語です (選べる言語: %s)",
+		"lang.selected": "言語を日本語にしました",
+
+		"storage.error": "ストレージエラー: %v",
+	})
+}
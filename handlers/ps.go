@@ -14,10 +14,10 @@ var psHandler = gobot.Handler{
 	Help:         "ps - list running/finished commands",
 	NeedsMention: true,
 	Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
-		return msg.Text == "ps"
+		return strings.EqualFold(msg.Text, "ps")
 	},
 	Handle: func(bot gobot.Bot, msg gobot.Message) error {
-		tasks := configurablecommand.GetTasks()
+		tasks := configurablecommand.GetTasks(msg.Workspace)
 		var tt []configurablecommand.Task
 		for _, task := range tasks {
 			if task.Msg.ChannelID != msg.ChannelID {
@@ -25,19 +25,22 @@ var psHandler = gobot.Handler{
 			}
 			tt = append(tt, task)
 		}
-		var ss []string
+		sections := []gobot.Section{{Title: msg.T("ps.title")}}
 		for _, task := range tt {
 			user, err := bot.LoadUser(task.Msg.UserID)
 			if err != nil {
-				user = "anonymous"
+				user = msg.T("ps.anonymous")
 			}
-			s := "  * " + strconv.Itoa(task.ID) + ". (" + task.Status().String() + ") " +
-				user + ": " + task.Msg.Text +
-				" (time: " + (task.Duration() / time.Millisecond * time.Millisecond).String() + ")"
-			ss = append(ss, s)
+			sections = append(sections, gobot.Section{
+				Text: "*" + strconv.Itoa(task.ID) + ".* `" + task.Msg.Text + "`",
+				Fields: []gobot.Field{
+					{Title: msg.T("field.status"), Value: task.Status().String()},
+					{Title: msg.T("field.user"), Value: user},
+					{Title: msg.T("field.time"), Value: (task.Duration() / time.Millisecond * time.Millisecond).String()},
+				},
+			})
 		}
-		text := "```\n" + "Latest commands:\n" + strings.Join(ss, "\n") + "\n```"
-		bot.SendMessage(text, msg.ChannelID)
+		bot.Send(gobot.OutgoingMessage{ChannelID: msg.ChannelID, Rich: &gobot.RichMessage{Sections: sections}})
 		return nil
 	},
 }
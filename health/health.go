@@ -0,0 +1,58 @@
+// Package health serves the liveness and readiness of the bot for supervisors, e.g. on /healthz and /readyz.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check reports a detail on success, e.g. "connected", or an error.
+type Check struct {
+	Name  string
+	Check func() (string, error)
+}
+
+// Result is the result of a check.
+type Result struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the result of all checks, it's ok only if all of them are.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Run runs the checks one by one.
+func Run(checks []Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result)}
+	for _, c := range checks {
+		detail, err := c.Check()
+		if err != nil {
+			report.Status = StatusFail
+			report.Checks[c.Name] = Result{Status: StatusFail, Detail: detail, Error: err.Error()}
+			continue
+		}
+		report.Checks[c.Name] = Result{Status: StatusOK, Detail: detail}
+	}
+	return report
+}
+
+// Handler serves the report of checks in JSON, with status 503 when any check fails.
+func Handler(checks ...Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := Run(checks)
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != StatusOK {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	ok := Check{Name: "connection", Check: func() (string, error) { return "connected", nil }}
+	fail := Check{Name: "store", Check: func() (string, error) { return "", errors.New("unable to open database file") }}
+
+	tests := []struct {
+		checks     []Check
+		wantCode   int
+		wantReport Report
+	}{
+		{
+			checks:   []Check{ok},
+			wantCode: http.StatusOK,
+			wantReport: Report{Status: StatusOK, Checks: map[string]Result{
+				"connection": {Status: StatusOK, Detail: "connected"},
+			}},
+		},
+		{
+			checks:   []Check{ok, fail},
+			wantCode: http.StatusServiceUnavailable,
+			wantReport: Report{Status: StatusFail, Checks: map[string]Result{
+				"connection": {Status: StatusOK, Detail: "connected"},
+				"store":      {Status: StatusFail, Error: "unable to open database file"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		Handler(tt.checks...).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if rec.Code != tt.wantCode {
+			t.Errorf("status = %v, want %v", rec.Code, tt.wantCode)
+		}
+		var report Report
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(report, tt.wantReport) {
+			t.Errorf("report = %+v, want %+v", report, tt.wantReport)
+		}
+	}
+}
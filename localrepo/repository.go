@@ -1,6 +1,8 @@
 package localrepo
 
 import (
+	"sync"
+
 	"github.com/jinzhu/gorm"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -11,11 +13,29 @@ type Repository interface {
 	Del(cond interface{}) error
 	GetOne(where interface{}, out interface{}) error
 	GetAll(where interface{}, out interface{}) error
+	Ping() error
 	Close() error
 }
 
+var (
+	pathMutex sync.RWMutex
+	path      = "./.bot.sqlite"
+)
+
+// SetPath sets the sqlite database opened by New, ./.bot.sqlite by default, and returns the previous one.
+func SetPath(p string) string {
+	pathMutex.Lock()
+	defer pathMutex.Unlock()
+	prev := path
+	path = p
+	return prev
+}
+
 func New() (Repository, error) {
-	db, err := gorm.Open("sqlite3", "./.bot.sqlite")
+	pathMutex.RLock()
+	p := path
+	pathMutex.RUnlock()
+	db, err := gorm.Open("sqlite3", p)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +66,10 @@ func (r *repo) GetAll(where interface{}, out interface{}) error {
 	return r.db.Where(where).Find(out).Error
 }
 
+func (r *repo) Ping() error {
+	return r.db.Exec("SELECT 1").Error
+}
+
 func (r *repo) Close() error {
 	return r.db.Close()
 }
@@ -1,21 +1,51 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/li-go/gobot/configurablecommand"
 	"github.com/li-go/gobot/gobot"
 	"github.com/li-go/gobot/handlers"
+	"github.com/li-go/gobot/health"
+	"github.com/li-go/gobot/metrics"
+	"github.com/li-go/gobot/plugin"
+	"github.com/li-go/gobot/transport"
 )
 
 var (
-	commandsCfg string
+	commandsCfg     string
+	pluginsCfg      string
+	permissionsCfg  string
+	workspacesCfg   string
+	transportName   string
+	eventsAddr      string
+	useConsole      bool
+	rateLimit       int
+	editWindow      time.Duration
+	adminChannel    string
+	language        string
+	prefixes        string
+	aliases         string
+	mentionAnywhere bool
+	metricsAddr     string
+
+	permissions map[string][]string
+
+	shutdownTimeout time.Duration
+	logLevel        string
+	logFormat       string
 )
 
 func usage(err error) {
@@ -26,7 +56,27 @@ func usage(err error) {
 
 func main() {
 	flag.StringVar(&commandsCfg, "c", "", "commands config in yaml format")
+	flag.StringVar(&pluginsCfg, "p", "", "plugins config in yaml format, executables providing handlers")
+	flag.StringVar(&workspacesCfg, "w", "", "workspaces config in yaml format, to run a bot for each of several slack workspaces")
+	flag.StringVar(&transportName, "transport", "rtm", "slack transport: rtm, events or socket")
+	flag.StringVar(&eventsAddr, "addr", ":3000", "listen address of events api, slash commands and button clicks receiver")
+	flag.BoolVar(&useConsole, "console", false, "talk to the bot through stdin/stdout instead of slack")
+	flag.StringVar(&adminChannel, "admin-channel", "", "channel id panics are reported to")
+	flag.StringVar(&prefixes, "prefix", "", "comma separated prefixes addressing the bot like a mention, e.g. ! for !dist-beta")
+	flag.StringVar(&aliases, "alias", "", "comma separated names addressing the bot at the start of a message, e.g. gobot for gobot: ps")
+	flag.BoolVar(&mentionAnywhere, "mention-anywhere", false, "address the bot by mentioning it anywhere in a message")
+	flag.StringVar(&permissionsCfg, "permissions", "", "permissions config in yaml format, handler names to the IDs of the users allowed to use them")
+	flag.IntVar(&rateLimit, "rate-limit", 0, "max messages handled per user per minute, 0 for no limit")
+	flag.StringVar(&language, "lang", gobot.DefaultLanguage, "language of replies unless users select theirs: "+strings.Join(gobot.Languages(), ", "))
+	flag.DurationVar(&editWindow, "edit-window", 5*time.Minute, "how long after received edited messages are handled again, 0 to ignore edits")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "listen address of /metrics, /healthz and /readyz, empty to disable")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 30*time.Second, "how long to wait for handlers and tasks on shutdown")
+	flag.StringVar(&logLevel, "log-level", "info", "log level: debug, info, warn or error")
+	flag.StringVar(&logFormat, "log-format", gobot.FormatLogfmt, "log format: logfmt or json")
 	flag.Parse()
+	if !gobot.IsLanguage(language) {
+		usage(fmt.Errorf("unknown language %s", language))
+	}
 
 	var commands []configurablecommand.Command
 	if len(commandsCfg) > 0 {
@@ -40,39 +90,249 @@ func main() {
 		}
 	}
 
-	logger := log.New(os.Stdout, "bot: ", log.LstdFlags)
-	bot, err := gobot.New(os.Getenv("SLACK_TOKEN"), logger)
+	var pluginConfigs []plugin.Config
+	if len(pluginsCfg) > 0 {
+		file, err := os.Open(pluginsCfg)
+		if err != nil {
+			usage(err)
+		}
+		err = yaml.NewDecoder(file).Decode(&pluginConfigs)
+		if err != nil {
+			usage(err)
+		}
+	}
+
+	if len(permissionsCfg) > 0 {
+		file, err := os.Open(permissionsCfg)
+		if err != nil {
+			usage(err)
+		}
+		err = yaml.NewDecoder(file).Decode(&permissions)
+		if err != nil {
+			usage(err)
+		}
+	}
+
+	workspaces := []workspace{defaultWorkspace()}
+	if len(workspacesCfg) > 0 {
+		if useConsole {
+			usage(errors.New("the console can't be used with several workspaces"))
+		}
+		var err error
+		workspaces, err = loadWorkspaces(workspacesCfg)
+		if err != nil {
+			usage(err)
+		}
+	}
+
+	level, err := gobot.ParseLevel(logLevel)
 	if err != nil {
 		usage(err)
 	}
+	logger := gobot.NewLogger(os.Stdout, level, logFormat)
+
+	var bots []gobot.Bot
+	var plugins []*plugin.Plugin
+	for _, w := range workspaces {
+		bot, ps, err := newBot(w, commands, pluginConfigs, logger)
+		if err != nil {
+			usage(err)
+		}
+		bots = append(bots, bot)
+		plugins = append(plugins, ps...)
+	}
+
+	if len(metricsAddr) > 0 {
+		go serveStatus(bots, plugins, logger)
+	}
+
+	// wait signal
+	signCh := make(chan os.Signal, 1)
+	signal.Notify(signCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signCh
+		for _, bot := range bots {
+			bot.Stop()
+		}
+	}()
+
+	// start, each returns when stopped
+	configurablecommand.StartScheduler()
+	var wg sync.WaitGroup
+	for _, bot := range bots {
+		wg.Add(1)
+		go func(bot gobot.Bot) {
+			defer wg.Done()
+			bot.Start()
+		}(bot)
+	}
+	wg.Wait()
+
+	// drain in-flight handlers first, they may still queue tasks
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, bot := range bots {
+		if err := bot.Shutdown(ctx); err != nil {
+			bot.GetLogger().Warn("fail to drain handlers", "err", err)
+		}
+	}
+	// plugins may start tasks until they're stopped
+	for _, p := range plugins {
+		if err := p.Stop(ctx); err != nil {
+			logger.Warn("fail to stop plugin", "plugin", p.Name(), "err", err)
+		}
+	}
+	if err := configurablecommand.Shutdown(ctx); err != nil {
+		logger.Warn("fail to finish tasks", "err", err)
+	}
+	logger.Info("shutdown completed")
+}
+
+// newBot connects a bot to the workspace, starts its plugins and restores its tasks.
+func newBot(w workspace, commands []configurablecommand.Command, pluginConfigs []plugin.Config, logger gobot.Logger) (gobot.Bot, []*plugin.Plugin, error) {
+	t, err := newTransport(w)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(w.Name) > 0 {
+		logger = logger.With("workspace", w.Name)
+	}
+	bot, err := gobot.New(t, logger,
+		gobot.OptionWorkspace(w.Name),
+		gobot.OptionAdminChannel(w.AdminChannel),
+		gobot.OptionEditWindow(editWindow),
+		gobot.OptionLanguage(w.Language),
+		gobot.OptionTriggers(gobot.Triggers{Prefixes: splitList(prefixes), Aliases: splitList(aliases), MentionAnywhere: mentionAnywhere}),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := handlers.LoadUserLanguages(bot); err != nil {
+		logger.Warn("fail to load user languages", "err", err)
+	}
+	bot.Use(gobot.Recovery(), gobot.Logging(), gobot.Metrics())
+	if rateLimit > 0 {
+		bot.Use(gobot.RateLimit(rateLimit, time.Minute))
+	}
+	if len(w.Permissions) > 0 {
+		bot.Use(gobot.Permission(w.Permissions))
+	}
 
 	// register defined handlers
 	for _, h := range handlers.All {
 		if err := bot.RegisterHandler(h); err != nil {
-			usage(err)
+			return nil, nil, err
+		}
+	}
+
+	for _, h := range handlers.Events {
+		if err := bot.RegisterEventHandler(h); err != nil {
+			return nil, nil, err
 		}
 	}
 
 	// register configurable command handlers
 	for _, c := range commands {
 		if err := bot.RegisterHandler(c.Handler()); err != nil {
-			usage(err)
+			return nil, nil, err
+		}
+	}
+
+	// start plugins and register their handlers
+	var plugins []*plugin.Plugin
+	for _, cfg := range pluginConfigs {
+		p := plugin.New(cfg, bot, commands)
+		if err := p.Start(); err != nil {
+			return nil, nil, err
+		}
+		plugins = append(plugins, p)
+		for _, h := range p.Handlers() {
+			if err := bot.RegisterHandler(h); err != nil {
+				return nil, nil, err
+			}
 		}
 	}
 
 	// load pending tasks
 	configurablecommand.LoadPendingTasks(bot)
+	return bot, plugins, nil
+}
 
-	// wait signal
-	signCh := make(chan os.Signal)
-	signal.Notify(signCh, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-signCh
-		configurablecommand.StopAll()
-		bot.Stop()
-		os.Exit(1)
-	}()
+// splitList splits a comma separated flag, empty items are dropped.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func serveStatus(bots []gobot.Bot, plugins []*plugin.Plugin, logger gobot.Logger) {
+	var connections, lastEvents, pluginChecks []health.Check
+	for _, bot := range bots {
+		bot := bot
+		suffix := ""
+		if len(bot.Workspace()) > 0 {
+			suffix = ":" + bot.Workspace()
+		}
+		connections = append(connections, health.Check{Name: "connection" + suffix, Check: func() (string, error) {
+			if !bot.Health().Connected {
+				return "", errors.New("disconnected")
+			}
+			return "connected", nil
+		}})
+		lastEvents = append(lastEvents, health.Check{Name: "last_event" + suffix, Check: func() (string, error) {
+			at := bot.Health().LastEventAt
+			if at.IsZero() {
+				return "none", nil
+			}
+			return at.Format(time.RFC3339), nil
+		}})
+	}
+	for _, p := range plugins {
+		name := "plugin:" + p.Name()
+		if len(p.Workspace()) > 0 {
+			name += ":" + p.Workspace()
+		}
+		pluginChecks = append(pluginChecks, health.Check{Name: name, Check: p.Check})
+	}
+	store := health.Check{Name: "store", Check: func() (string, error) {
+		return "", configurablecommand.CheckStore()
+	}}
+	scheduler := health.Check{Name: "scheduler", Check: func() (string, error) {
+		return "", configurablecommand.CheckScheduler()
+	}}
 
-	// start
-	bot.Start()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	// alive as long as tasks get scheduled, ready when slack and the task store are reachable as well
+	mux.Handle("/healthz", health.Handler(append([]health.Check{scheduler}, lastEvents...)...))
+	mux.Handle("/readyz", health.Handler(append(append(append(connections, store, scheduler), lastEvents...), pluginChecks...)...))
+	if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+		logger.Error("fail to serve status", "err", err)
+	}
+}
+
+func newTransport(w workspace) (gobot.Transport, error) {
+	if useConsole {
+		return transport.NewConsole(os.Stdin, os.Stdout), nil
+	}
+
+	switch transportName {
+	case "rtm":
+		// slash commands and button clicks need an HTTP endpoint with RTM
+		if len(w.SigningSecret) > 0 {
+			return transport.NewRTM(w.Token, transport.OptionInteractions(w.SigningSecret, w.Addr)), nil
+		}
+		return transport.NewRTM(w.Token), nil
+	case "events":
+		return transport.NewEventsAPI(w.Token, w.SigningSecret, w.Addr), nil
+	case "socket":
+		return transport.NewSocketMode(w.Token, w.AppToken), nil
+	default:
+		return nil, fmt.Errorf("unknown transport: %s", transportName)
+	}
 }
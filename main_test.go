@@ -1,9 +1,59 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"reflect"
 	"testing"
 )
 
 func Test(t *testing.T) {
 	t.Skip("No tests")
 }
+
+func TestLoadWorkspaces(t *testing.T) {
+	transportName = "events"
+	eventsAddr = ":3000"
+	language = "en"
+	os.Setenv("TEAM_B_TOKEN", "xoxb-b")
+	defer os.Unsetenv("TEAM_B_TOKEN")
+
+	tests := []struct {
+		yaml    string
+		want    []workspace
+		wantErr bool
+	}{
+		{
+			yaml: "- name: team-a\n  token: xoxb-a\n  permissions:\n    kill: [U123]\n- name: team-b\n  token: ${TEAM_B_TOKEN}\n  addr: \":3001\"\n  language: ja\n",
+			want: []workspace{
+				{Name: "team-a", Token: "xoxb-a", Addr: ":3000", Language: "en", Permissions: map[string][]string{"kill": {"U123"}}},
+				{Name: "team-b", Token: "xoxb-b", Addr: ":3001", Language: "ja"},
+			},
+		},
+		{yaml: "- name: team-a\n  token: xoxb-a\n- name: team-b\n  token: xoxb-b\n", wantErr: true},
+		{yaml: "- name: team-a\n  token: xoxb-a\n- name: team-a\n  token: xoxb-b\n  addr: \":3001\"\n", wantErr: true},
+		{yaml: "- name: team-a\n", wantErr: true},
+		{yaml: "- name: team-a\n  token: xoxb-a\n  language: fr\n", wantErr: true},
+		{yaml: "[]", wantErr: true},
+	}
+	for _, tt := range tests {
+		file, err := ioutil.TempFile("", "workspaces")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(file.Name())
+		if _, err := file.WriteString(tt.yaml); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+
+		got, err := loadWorkspaces(file.Name())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadWorkspaces(%q) error = %v, wantErr %v", tt.yaml, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("loadWorkspaces(%q) = %+v, want %+v", tt.yaml, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package metrics exposes counters, gauges and histograms in the Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// DefBuckets are the default latency buckets in seconds
+	DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// Default is the registry the metrics of gobot are registered to
+	Default = NewRegistry()
+)
+
+type collector interface {
+	write(w io.Writer)
+}
+
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every metric in the Prometheus text format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mutex.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.mutex.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the metrics, it's usually mounted on /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_ = r.Write(w)
+	})
+}
+
+type desc struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+}
+
+func (d desc) writeHeader(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.typ)
+}
+
+// series formats the labels of a sample, extra is appended as is, e.g. `le="1"`.
+func (d desc) series(name string, labelValues []string, extra string) string {
+	var labels []string
+	for i, n := range d.labelNames {
+		labels = append(labels, n+`="`+escape(labelValues[i])+`"`)
+	}
+	if len(extra) > 0 {
+		labels = append(labels, extra)
+	}
+	if len(labels) == 0 {
+		return name
+	}
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// key joins label values to index a series.
+func key(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec counts events by labels.
+type CounterVec struct {
+	desc
+
+	mutex       sync.Mutex
+	labelValues map[string][]string
+	values      map[string]float64
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		desc:        desc{name: name, help: help, typ: "counter", labelNames: labelNames},
+		labelValues: make(map[string][]string),
+		values:      make(map[string]float64),
+	}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	k := key(labelValues)
+	c.labelValues[k] = labelValues
+	c.values[k] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.writeHeader(w)
+	for _, k := range sortedKeys(c.labelValues) {
+		fmt.Fprintf(w, "%s %s\n", c.series(c.name, c.labelValues[k], ""), formatFloat(c.values[k]))
+	}
+}
+
+// Sample is a value of a GaugeFunc.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// GaugeFunc reports the samples returned by f on every scrape.
+type GaugeFunc struct {
+	desc
+	f func() []Sample
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, f func() []Sample) *GaugeFunc {
+	g := &GaugeFunc{desc: desc{name: name, help: help, typ: "gauge", labelNames: labelNames}, f: f}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.writeHeader(w)
+	for _, s := range g.f() {
+		fmt.Fprintf(w, "%s %s\n", g.series(g.name, s.LabelValues, ""), formatFloat(s.Value))
+	}
+}
+
+// HistogramVec counts observations in buckets by labels.
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	mutex       sync.Mutex
+	labelValues map[string][]string
+	counts      map[string][]uint64
+	sums        map[string]float64
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	h := &HistogramVec{
+		desc:        desc{name: name, help: help, typ: "histogram", labelNames: labelNames},
+		buckets:     append(append([]float64{}, buckets...), math.Inf(1)),
+		labelValues: make(map[string][]string),
+		counts:      make(map[string][]uint64),
+		sums:        make(map[string]float64),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	k := key(labelValues)
+	if _, ok := h.counts[k]; !ok {
+		h.labelValues[k] = labelValues
+		h.counts[k] = make([]uint64, len(h.buckets))
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[k][i]++
+		}
+	}
+	h.sums[k] += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.writeHeader(w)
+	for _, k := range sortedKeys(h.labelValues) {
+		lv := h.labelValues[k]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_bucket", lv, `le="`+formatFloat(b)+`"`), h.counts[k][i])
+		}
+		fmt.Fprintf(w, "%s %s\n", h.series(h.name+"_sum", lv, ""), formatFloat(h.sums[k]))
+		fmt.Fprintf(w, "%s %d\n", h.series(h.name+"_count", lv, ""), h.counts[k][len(h.buckets)-1])
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	messages := r.NewCounterVec("gobot_messages_received_total", "Messages received.", "type")
+	r.NewGaugeFunc("gobot_tasks_pending", "Pending tasks.", nil, func() []Sample {
+		return []Sample{{Value: 2}}
+	})
+	durations := r.NewHistogramVec("gobot_task_duration_seconds", "Task durations.", []float64{1, 10}, "command")
+
+	messages.Inc("ReplyTo")
+	messages.Inc("ReplyTo")
+	messages.Inc(`List"en`)
+	durations.Observe(0.5, "deploy")
+	durations.Observe(5, "deploy")
+	durations.Observe(30, "deploy")
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP gobot_messages_received_total Messages received.
+# TYPE gobot_messages_received_total counter
+gobot_messages_received_total{type="List\"en"} 1
+gobot_messages_received_total{type="ReplyTo"} 2
+# HELP gobot_tasks_pending Pending tasks.
+# TYPE gobot_tasks_pending gauge
+gobot_tasks_pending 2
+# HELP gobot_task_duration_seconds Task durations.
+# TYPE gobot_task_duration_seconds histogram
+gobot_task_duration_seconds_bucket{command="deploy",le="1"} 1
+gobot_task_duration_seconds_bucket{command="deploy",le="10"} 2
+gobot_task_duration_seconds_bucket{command="deploy",le="+Inf"} 3
+gobot_task_duration_seconds_sum{command="deploy"} 35.5
+gobot_task_duration_seconds_count{command="deploy"} 3
+`
+	if buf.String() != want {
+		t.Errorf("metrics = %v, want %v", buf.String(), want)
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("gobot_reconnects_total", "Reconnects.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if want := "gobot_reconnects_total 1\n"; !bytes.HasSuffix(rec.Body.Bytes(), []byte(want)) {
+		t.Errorf("body = %v, want suffix %v", rec.Body.String(), want)
+	}
+}
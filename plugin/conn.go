@@ -0,0 +1,195 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLineLength is the longest line a plugin can write
+	maxLineLength = 1024 * 1024
+)
+
+var (
+	// writeTimeout is how long the responses to the requests of a plugin may take to be written
+	writeTimeout = 10 * time.Second
+)
+
+// serveFunc serves a request of the plugin, a returned *Error is sent as is.
+type serveFunc func(method string, params json.RawMessage) (interface{}, error)
+
+// conn is a JSON-RPC 2.0 connection with a plugin over its stdin and stdout, one message per line.
+// Both ends make requests, the ones of the plugin are served by serve concurrently.
+type conn struct {
+	serve serveFunc
+
+	writeMutex sync.Mutex
+	w          io.Writer
+
+	mutex   sync.Mutex
+	lastID  int64
+	pending map[string]chan frame
+	err     error
+	closed  chan struct{}
+	// stuck is closed once a write times out, the plugin doesn't read its stdin anymore
+	stuck     chan struct{}
+	stuckOnce sync.Once
+}
+
+// newConn reads r until it's closed or unreadable, e.g. a line is too long, then the pending calls fail.
+func newConn(r io.Reader, w io.Writer, serve serveFunc) *conn {
+	c := &conn{serve: serve, w: w, pending: make(map[string]chan frame), closed: make(chan struct{}), stuck: make(chan struct{})}
+	go c.read(r)
+	return c
+}
+
+// call calls method of the plugin with params and decodes the result into result unless it's nil.
+func (c *conn) call(ctx context.Context, method string, params, result interface{}) error {
+	buf, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	if c.err != nil {
+		c.mutex.Unlock()
+		return c.err
+	}
+	c.lastID++
+	id := json.RawMessage(strconv.FormatInt(c.lastID, 10))
+	ch := make(chan frame, 1)
+	c.pending[string(id)] = ch
+	c.mutex.Unlock()
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, string(id))
+		c.mutex.Unlock()
+	}()
+
+	if err := c.write(ctx, frame{ID: &id, Method: method, Params: buf}); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.closed:
+		return c.closeErr()
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(resp.Result, result)
+	}
+}
+
+func (c *conn) closeErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.err
+}
+
+// write writes f unless ctx is done first, the conn is stuck from then on as a line may be half written.
+func (c *conn) write(ctx context.Context, f frame) error {
+	f.JSONRPC = "2.0"
+	buf, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	written := make(chan error, 1)
+	go func() {
+		c.writeMutex.Lock()
+		defer c.writeMutex.Unlock()
+		_, err := c.w.Write(append(buf, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		return err
+	case <-ctx.Done():
+		c.stuckOnce.Do(func() {
+			close(c.stuck)
+		})
+		return fmt.Errorf("fail to write to plugin: %w", ctx.Err())
+	}
+}
+
+// writeResponse writes a response to a request of the plugin within writeTimeout.
+func (c *conn) writeResponse(f frame) {
+	ctx, cancel := context.WithTimeout(context.Background(), writeTimeout)
+	defer cancel()
+	_ = c.write(ctx, f)
+}
+
+func (c *conn) read(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	for scanner.Scan() {
+		var f frame
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			c.writeResponse(frame{ID: nullID(), Error: &Error{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+		if len(f.Method) == 0 {
+			c.deliver(f)
+			continue
+		}
+		go c.respond(f)
+	}
+	c.mutex.Lock()
+	c.err = ErrExited
+	if err := scanner.Err(); err != nil {
+		c.err = fmt.Errorf("fail to read from plugin: %w", err)
+	}
+	c.mutex.Unlock()
+	close(c.closed)
+}
+
+// deliver passes a response to the call waiting for it, responses to calls given up on are dropped.
+func (c *conn) deliver(f frame) {
+	if f.ID == nil {
+		return
+	}
+	c.mutex.Lock()
+	ch, ok := c.pending[string(*f.ID)]
+	c.mutex.Unlock()
+	if ok {
+		ch <- f
+	}
+}
+
+// respond serves a request of the plugin, notifications get no response.
+func (c *conn) respond(req frame) {
+	result, err := c.serve(req.Method, req.Params)
+	if req.ID == nil {
+		return
+	}
+	resp := frame{ID: req.ID}
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: codeServerError, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+	} else {
+		buf, err := json.Marshal(result)
+		if err != nil {
+			resp.Error = &Error{Code: codeServerError, Message: err.Error()}
+		} else {
+			resp.Result = buf
+		}
+	}
+	c.writeResponse(resp)
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
@@ -0,0 +1,411 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+)
+
+var (
+	// HelloTimeout is how long a launched plugin may take to answer the hello
+	HelloTimeout = 10 * time.Second
+	// RestartDelay is the delay before a crashed plugin is restarted, doubled up to MaxRestartDelay while it keeps crashing
+	RestartDelay    = time.Second
+	MaxRestartDelay = time.Minute
+	// DefaultTimeout is how long a handler of a plugin may take unless configured
+	DefaultTimeout = 30 * time.Second
+)
+
+var (
+	ErrExited         = errors.New("plugin exited")
+	ErrNotRunning     = errors.New("plugin not running")
+	ErrNoHandler      = errors.New("plugin no longer provides the handler")
+	ErrUnknownCommand = errors.New("unknown command")
+)
+
+// Config is a plugin, an executable talking the protocol over its stdin and stdout.
+type Config struct {
+	Name string `yaml:"name"`
+	// Command is run with bash -c
+	Command string `yaml:"command"`
+	// Timeout is how long a handler of the plugin may take, DefaultTimeout if not set
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Plugin runs a plugin and restarts it when it crashes, until stopped.
+type Plugin struct {
+	config   Config
+	bot      gobot.Bot
+	commands []configurablecommand.Command
+	logger   gobot.Logger
+
+	mutex sync.Mutex
+	proc  *process
+	specs map[string]spec
+	names []string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	kill     chan struct{}
+	done     chan struct{}
+}
+
+// process is a launch of a plugin.
+type process struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	conn      *conn
+	startedAt time.Time
+	// exited is closed once the process has exited, err tells why
+	exited chan struct{}
+	err    error
+}
+
+type spec struct {
+	HandlerSpec
+	re *regexp.Regexp
+}
+
+// New creates a plugin of bot, its tasks are started from commands.
+func New(config Config, bot gobot.Bot, commands []configurablecommand.Command) *Plugin {
+	return &Plugin{
+		config:   config,
+		bot:      bot,
+		commands: commands,
+		logger:   bot.GetLogger().With("plugin", config.Name),
+		stop:     make(chan struct{}),
+		kill:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *Plugin) Name() string {
+	return p.config.Name
+}
+
+// Workspace is the workspace of the bot of the plugin.
+func (p *Plugin) Workspace() string {
+	return p.bot.Workspace()
+}
+
+// Start launches the plugin and says hello, it's supervised from then on.
+func (p *Plugin) Start() error {
+	if err := p.launch(); err != nil {
+		return err
+	}
+	go p.supervise()
+	return nil
+}
+
+// Stop closes the stdin of the plugin and waits for it to exit, it's killed when ctx is done.
+func (p *Plugin) Stop(ctx context.Context) error {
+	if p.current() == nil {
+		return nil
+	}
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		close(p.kill)
+		<-p.done
+		return ctx.Err()
+	}
+}
+
+// Check tells whether the plugin is running, for readiness checks.
+func (p *Plugin) Check() (string, error) {
+	proc := p.current()
+	if proc == nil {
+		return "", ErrNotRunning
+	}
+	select {
+	case <-proc.exited:
+		return "", fmt.Errorf("%w: %v", ErrNotRunning, proc.err)
+	default:
+		return fmt.Sprintf("running since %s", proc.startedAt.Format(time.RFC3339)), nil
+	}
+}
+
+// Handlers returns the handlers the plugin said it provides when started.
+// The ones it drops after a restart no longer handle anything, the ones it adds aren't registered.
+func (p *Plugin) Handlers() []gobot.Handler {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var handlers []gobot.Handler
+	for _, name := range p.names {
+		name := name
+		s := p.specs[name]
+		handlers = append(handlers, gobot.Handler{
+			Name:         s.Name,
+			Help:         s.Help,
+			NeedsMention: s.NeedsMention,
+			Handleable: func(bot gobot.Bot, msg gobot.Message) bool {
+				s, ok := p.spec(name)
+				return ok && s.re.MatchString(msg.Text)
+			},
+			Handle: func(bot gobot.Bot, msg gobot.Message) error {
+				return p.handle(name, msg)
+			},
+		})
+	}
+	return handlers
+}
+
+func (p *Plugin) current() *process {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.proc
+}
+
+func (p *Plugin) spec(name string) (spec, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	s, ok := p.specs[name]
+	return s, ok
+}
+
+func (p *Plugin) handle(name string, msg gobot.Message) error {
+	s, ok := p.spec(name)
+	if !ok {
+		return ErrNoHandler
+	}
+	timeout := p.config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	proc := p.current()
+	if proc == nil {
+		return ErrNotRunning
+	}
+	// between restarts the current process is the one exited
+	select {
+	case <-proc.exited:
+		return fmt.Errorf("%w: %v", ErrNotRunning, proc.err)
+	default:
+	}
+	params := HandleParams{Handler: name, Message: messageOf(msg), Match: s.re.FindStringSubmatch(msg.Text)}
+	return proc.conn.call(ctx, MethodHandle, params, nil)
+}
+
+// launch runs the command of the plugin and says hello, then makes it the current process.
+func (p *Plugin) launch() error {
+	cmd := exec.Command("bash", "-c", p.config.Command)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("fail to start plugin %s: %w", p.config.Name, err)
+	}
+
+	proc := &process{cmd: cmd, stdin: stdin, startedAt: time.Now(), exited: make(chan struct{})}
+	proc.conn = newConn(stdout, stdin, p.serve)
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			p.logger.Warn(scanner.Text())
+		}
+	}()
+	go func() {
+		// the pipes are closed by Wait, so it waits for them to be read up
+		select {
+		case <-proc.conn.closed:
+			// the plugin isn't read anymore though it's still running, e.g. it wrote a line too long
+			if proc.conn.closeErr() != ErrExited {
+				_ = cmd.Process.Kill()
+			}
+		case <-proc.conn.stuck:
+			// the plugin doesn't read its stdin anymore, the writes to it would block
+			_ = cmd.Process.Kill()
+			<-proc.conn.closed
+		}
+		<-logged
+		proc.err = cmd.Wait()
+		if proc.err == nil {
+			proc.err = ErrExited
+		}
+		close(proc.exited)
+	}()
+
+	specs, names, err := p.hello(proc)
+	if err != nil {
+		stdin.Close()
+		_ = cmd.Process.Kill()
+		<-proc.exited
+		return fmt.Errorf("fail to say hello to plugin %s: %w", p.config.Name, err)
+	}
+
+	p.mutex.Lock()
+	p.specs = specs
+	if p.names == nil {
+		p.names = names
+	}
+	p.proc = proc
+	p.mutex.Unlock()
+	return nil
+}
+
+func (p *Plugin) hello(proc *process) (map[string]spec, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), HelloTimeout)
+	defer cancel()
+	var result HelloResult
+	if err := proc.conn.call(ctx, MethodHello, HelloParams{Protocol: ProtocolVersion, Workspace: p.bot.Workspace()}, &result); err != nil {
+		return nil, nil, err
+	}
+
+	specs := make(map[string]spec)
+	var names []string
+	for _, h := range result.Handlers {
+		if len(h.Name) == 0 {
+			return nil, nil, errors.New("handler without name")
+		}
+		if _, ok := specs[h.Name]; ok {
+			return nil, nil, fmt.Errorf("duplicated handler %s", h.Name)
+		}
+		// matched ignoring case like the built-in handlers
+		re, err := regexp.Compile("(?i)" + h.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid pattern of handler %s: %w", h.Name, err)
+		}
+		if len(h.Help) == 0 {
+			h.Help = h.Name
+		}
+		specs[h.Name] = spec{HandlerSpec: h, re: re}
+		names = append(names, h.Name)
+	}
+	return specs, names, nil
+}
+
+// supervise restarts the plugin whenever it exits until stopped.
+func (p *Plugin) supervise() {
+	defer close(p.done)
+	delay := RestartDelay
+	for {
+		proc := p.current()
+		select {
+		case <-proc.exited:
+		case <-p.stop:
+			proc.stdin.Close()
+			select {
+			case <-proc.exited:
+			case <-p.kill:
+				_ = proc.cmd.Process.Kill()
+				<-proc.exited
+			}
+			return
+		}
+
+		p.logger.Error("plugin exited", "err", proc.err)
+		if time.Since(proc.startedAt) > MaxRestartDelay {
+			delay = RestartDelay
+		}
+		for {
+			select {
+			case <-time.After(delay):
+			case <-p.stop:
+				return
+			}
+			if delay *= 2; delay > MaxRestartDelay {
+				delay = MaxRestartDelay
+			}
+			if err := p.launch(); err != nil {
+				p.logger.Error("fail to restart plugin", "err", err)
+				continue
+			}
+			p.logger.Info("plugin restarted")
+			break
+		}
+	}
+}
+
+// serve serves the requests of the plugin.
+func (p *Plugin) serve(method string, params json.RawMessage) (interface{}, error) {
+	switch method {
+	case MethodReply:
+		var ps ReplyParams
+		if err := json.Unmarshal(params, &ps); err != nil {
+			return nil, invalidParams(err)
+		}
+		msg := ps.Message.gobotMessage()
+		out := gobot.OutgoingMessage{ChannelID: msg.ChannelID, Text: ps.Text}
+		if ps.InThread {
+			out.ThreadTS = msg.Thread()
+		}
+		ref, err := p.bot.Send(out)
+		if err != nil {
+			return nil, err
+		}
+		return ReplyResult{ChannelID: ref.ChannelID, TS: ref.TS}, nil
+	case MethodReact:
+		var ps ReactParams
+		if err := json.Unmarshal(params, &ps); err != nil {
+			return nil, invalidParams(err)
+		}
+		if err := p.bot.AddReaction(ps.Name, ps.Message.gobotMessage().Ref()); err != nil {
+			return nil, err
+		}
+		return struct{}{}, nil
+	case MethodStartTask:
+		var ps StartTaskParams
+		if err := json.Unmarshal(params, &ps); err != nil {
+			return nil, invalidParams(err)
+		}
+		c, ok := p.command(ps.CommandLine)
+		if !ok {
+			return nil, invalidParams(fmt.Errorf("%w: %s", ErrUnknownCommand, ps.CommandLine))
+		}
+		msg := ps.Message.gobotMessage()
+		msg.Text = ps.CommandLine
+		id, err := c.StartTask(p.bot, msg)
+		if err != nil {
+			return nil, err
+		}
+		return StartTaskResult{TaskID: id}, nil
+	default:
+		return nil, &Error{Code: codeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// command finds the command of commandLine by its first word.
+func (p *Plugin) command(commandLine string) (configurablecommand.Command, bool) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return configurablecommand.Command{}, false
+	}
+	for _, c := range p.commands {
+		if strings.EqualFold(c.Name, fields[0]) {
+			return c, true
+		}
+	}
+	return configurablecommand.Command{}, false
+}
+
+func invalidParams(err error) *Error {
+	return &Error{Code: codeInvalidParams, Message: err.Error()}
+}
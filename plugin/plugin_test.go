@@ -0,0 +1,287 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/configurablecommand"
+	"github.com/li-go/gobot/gobot"
+	"github.com/li-go/gobot/gobottest"
+)
+
+// TestHelperPlugin isn't a test, it's the plugin the tests launch by running the test binary.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PLUGIN") != "1" {
+		return
+	}
+	deaf := make(chan struct{})
+	var deafOnce sync.Once
+	var c *conn
+	c = newConn(deafReader{r: os.Stdin, deaf: deaf}, os.Stdout, func(method string, params json.RawMessage) (interface{}, error) {
+		switch method {
+		case MethodHello:
+			return HelloResult{Handlers: []HandlerSpec{
+				{Name: "echo", Help: "echo <text>", Pattern: `^echo (.+)$`, NeedsMention: true},
+				{Name: "like", Pattern: `^like$`, NeedsMention: true},
+				{Name: "ship", Help: "ship <branch>", Pattern: `^ship (\S+)$`, NeedsMention: true},
+				{Name: "fail", Pattern: `^(fail|crash)$`, NeedsMention: true},
+				{Name: "flood", Pattern: `^flood$`, NeedsMention: true},
+				{Name: "deaf", Pattern: `^deaf$`, NeedsMention: true},
+			}}, nil
+		case MethodHandle:
+			var ps HandleParams
+			if err := json.Unmarshal(params, &ps); err != nil {
+				return nil, err
+			}
+			ctx := context.Background()
+			switch ps.Handler {
+			case "echo":
+				return nil, c.call(ctx, MethodReply, ReplyParams{Message: ps.Message, Text: ps.Match[1], InThread: true}, nil)
+			case "like":
+				return nil, c.call(ctx, MethodReact, ReactParams{Message: ps.Message, Name: "+1"}, nil)
+			case "flood":
+				// a line too long for gobot, the plugin keeps running
+				_, err := os.Stdout.Write(bytes.Repeat([]byte("x"), maxLineLength+1))
+				return nil, err
+			case "deaf":
+				// stdin isn't read anymore, the plugin keeps running
+				deafOnce.Do(func() {
+					close(deaf)
+				})
+				return nil, nil
+			case "ship":
+				var result StartTaskResult
+				if err := c.call(ctx, MethodStartTask, StartTaskParams{Message: ps.Message, CommandLine: "deploy --branch " + ps.Match[1]}, &result); err != nil {
+					return nil, err
+				}
+				if result.TaskID <= 0 {
+					return nil, fmt.Errorf("invalid task id %d", result.TaskID)
+				}
+				return nil, c.call(ctx, MethodReply, ReplyParams{Message: ps.Message, Text: "shipping " + ps.Match[1]}, nil)
+			default:
+				if ps.Match[1] == "crash" {
+					os.Exit(1)
+				}
+				return nil, &Error{Code: codeServerError, Message: "boom"}
+			}
+		default:
+			return nil, &Error{Code: codeMethodNotFound, Message: "method not found: " + method}
+		}
+	})
+	<-c.closed
+	os.Exit(0)
+}
+
+// deafReader reads r until deaf is closed, then blocks forever.
+type deafReader struct {
+	r    io.Reader
+	deaf chan struct{}
+}
+
+func (r deafReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.deaf:
+		select {}
+	default:
+	}
+	return r.r.Read(p)
+}
+
+func startTestPlugin(t *testing.T, bot gobot.Bot, commands []configurablecommand.Command) *Plugin {
+	p := New(Config{Name: "test", Command: "GO_WANT_HELPER_PLUGIN=1 " + os.Args[0] + " -test.run=TestHelperPlugin", Timeout: 5 * time.Second}, bot, commands)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// texts returns the texts of msgs, the first section of rich messages leaving out their fields.
+func texts(msgs []gobot.OutgoingMessage) []string {
+	var tt []string
+	for _, msg := range msgs {
+		if msg.Rich != nil && len(msg.Rich.Sections) > 0 {
+			tt = append(tt, msg.Rich.Sections[0].Text)
+			continue
+		}
+		tt = append(tt, msg.Text)
+	}
+	return tt
+}
+
+func TestPlugin(t *testing.T) {
+	b := gobottest.NewBot(t)
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	commands := []configurablecommand.Command{
+		{Name: "deploy", Command: "deploy() { echo post_slack_begin; echo deployed $2; echo post_slack_end; }; deploy", ParamNames: []string{"branch"}},
+	}
+	p := startTestPlugin(t, b, commands)
+	defer p.Stop(context.Background())
+	for _, h := range p.Handlers() {
+		if err := b.RegisterHandler(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		text          string
+		want          []string
+		wantReactions []string
+		wantErr       error
+	}{
+		{text: "@gobot echo hello", want: []string{"hello"}},
+		{text: "@gobot ECHO Hello", want: []string{"Hello"}},
+		{text: "echo hello", wantErr: gobottest.ErrNotHandled},
+		{text: "@gobot like", wantReactions: []string{"+1"}},
+		{text: "@gobot ship main", want: []string{"shipping main"}, wantReactions: []string{"eyes"}},
+		{text: "@gobot fail", want: []string{"<@U123> *failed* - `fail` :see_no_evil:"}, wantErr: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		got, err := b.Say("U123", "C123", tt.text)
+		if !reflect.DeepEqual(err, tt.wantErr) && (err == nil || tt.wantErr == nil || err.Error() != tt.wantErr.Error()) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		if !reflect.DeepEqual(texts(got), tt.want) {
+			t.Errorf("Say(%q) = %q, want %q", tt.text, texts(got), tt.want)
+		}
+		if got := b.Reactions(b.LastSaid().Ref()); !reflect.DeepEqual(got, tt.wantReactions) {
+			t.Errorf("reactions of %q = %v, want %v", tt.text, got, tt.wantReactions)
+		}
+	}
+
+	got := texts(b.RunTasks())
+	if want := []string{"deployed main", "<@U123> *succeeded* - `deploy --branch main` :open_mouth:"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RunTasks() = %q, want %q", got, want)
+	}
+}
+
+func TestPlugin_Restart(t *testing.T) {
+	defer func(d time.Duration) { RestartDelay = d }(RestartDelay)
+	RestartDelay = 10 * time.Millisecond
+
+	b := gobottest.NewBot(t)
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	p := startTestPlugin(t, b, nil)
+	for _, h := range p.Handlers() {
+		if err := b.RegisterHandler(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		text    string
+		wantErr error
+	}{
+		{text: "@gobot crash", wantErr: ErrExited},
+		{text: "@gobot flood", wantErr: bufio.ErrTooLong},
+	}
+	for _, tt := range tests {
+		if _, err := b.Say("U123", "C123", tt.text); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Say(%q) error = %v, want %v", tt.text, err, tt.wantErr)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		var got []gobot.OutgoingMessage
+		for {
+			var err error
+			if got, err = b.Say("U123", "C123", "@gobot echo again"); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("plugin not restarted after %q: %v", tt.text, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if want := []string{"again"}; !reflect.DeepEqual(texts(got), want) {
+			t.Errorf("Say(echo again) after %q = %q, want %q", tt.text, texts(got), want)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Stop(ctx); err != nil {
+		t.Errorf("Stop() = %v", err)
+	}
+	if _, err := p.Check(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Check() after Stop() error = %v, want %v", err, ErrNotRunning)
+	}
+}
+
+func TestPlugin_Restart_deaf(t *testing.T) {
+	defer func(d time.Duration) { RestartDelay = d }(RestartDelay)
+	RestartDelay = 10 * time.Millisecond
+
+	b := gobottest.NewBot(t)
+	defer b.Close()
+	b.AddUser(gobot.User{ID: "U123", DisplayName: "alice"})
+	b.AddChannel(gobot.Channel{ID: "C123", Name: "general"})
+	p := New(Config{Name: "test", Command: "GO_WANT_HELPER_PLUGIN=1 " + os.Args[0] + " -test.run=TestHelperPlugin", Timeout: time.Second}, b, nil)
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop(context.Background())
+	for _, h := range p.Handlers() {
+		if err := b.RegisterHandler(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := b.Say("U123", "C123", "@gobot deaf"); err != nil {
+		t.Fatalf("Say(deaf) error = %v", err)
+	}
+	// longer than the pipe buffer, so that the write blocks
+	if _, err := b.Say("U123", "C123", "@gobot echo "+strings.Repeat("x", 1024*1024)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Say(echo x...) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := b.Say("U123", "C123", "@gobot echo again")
+		if err == nil {
+			if want := []string{"again"}; !reflect.DeepEqual(texts(got), want) {
+				t.Errorf("Say(echo again) = %q, want %q", texts(got), want)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin not restarted: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPlugin_handle_notRunning(t *testing.T) {
+	b := gobottest.NewBot(t)
+	defer b.Close()
+	exited := make(chan struct{})
+	close(exited)
+
+	tests := []struct {
+		name string
+		proc *process
+	}{
+		{name: "not started"},
+		{name: "exited", proc: &process{exited: exited, err: ErrExited}},
+	}
+	for _, tt := range tests {
+		p := New(Config{Name: "test"}, b, nil)
+		p.specs = map[string]spec{"echo": {HandlerSpec: HandlerSpec{Name: "echo"}, re: regexp.MustCompile(`^echo (.+)$`)}}
+		p.proc = tt.proc
+		if err := p.handle("echo", gobot.Message{Text: "echo hello"}); !errors.Is(err, ErrNotRunning) {
+			t.Errorf("%s: handle() error = %v, want %v", tt.name, err, ErrNotRunning)
+		}
+	}
+}
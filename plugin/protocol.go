@@ -0,0 +1,159 @@
+package plugin
+
+import (
+	"encoding/json"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+// ProtocolVersion is sent in the hello, plugins should refuse versions they don't know.
+const ProtocolVersion = 1
+
+// the methods gobot calls
+const (
+	// MethodHello is called once the plugin is launched, it returns the handlers the plugin provides
+	MethodHello = "hello"
+	// MethodHandle is called with a message matching a handler of the plugin
+	MethodHandle = "handle"
+)
+
+// the methods plugins call
+const (
+	// MethodReply sends a text into the channel of a message, or its thread
+	MethodReply = "reply"
+	// MethodReact reacts to a message with an emoji
+	MethodReact = "react"
+	// MethodStartTask queues a task of a configured command
+	MethodStartTask = "start_task"
+)
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+// frame is a JSON-RPC 2.0 request, notification or response, one per line.
+type frame struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error, the message of the error a handler returns is replied to the user.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type HelloParams struct {
+	Protocol  int    `json:"protocol"`
+	Workspace string `json:"workspace,omitempty"`
+}
+
+type HelloResult struct {
+	Handlers []HandlerSpec `json:"handlers"`
+}
+
+// HandlerSpec describes a handler of a plugin, the messages whose text matches Pattern ignoring case are sent to it.
+type HandlerSpec struct {
+	Name         string `json:"name"`
+	Help         string `json:"help"`
+	Pattern      string `json:"pattern"`
+	NeedsMention bool   `json:"needs_mention"`
+}
+
+type HandleParams struct {
+	Handler string  `json:"handler"`
+	Message Message `json:"message"`
+	// Match is the match of the pattern of the handler and its submatches
+	Match []string `json:"match"`
+}
+
+// Message is a gobot.Message as plugins see it.
+type Message struct {
+	Type      string `json:"type"`
+	Text      string `json:"text"`
+	ChannelID string `json:"channel_id"`
+	UserID    string `json:"user_id"`
+	TS        string `json:"ts,omitempty"`
+	ThreadTS  string `json:"thread_ts,omitempty"`
+	Workspace string `json:"workspace,omitempty"`
+	Lang      string `json:"lang,omitempty"`
+
+	Users    []gobot.Entity `json:"users,omitempty"`
+	Channels []gobot.Entity `json:"channels,omitempty"`
+	Groups   []gobot.Entity `json:"groups,omitempty"`
+	Links    []gobot.Link   `json:"links,omitempty"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+func messageOf(msg gobot.Message) Message {
+	return Message{
+		Type:          msg.Type.String(),
+		Text:          msg.Text,
+		ChannelID:     msg.ChannelID,
+		UserID:        msg.UserID,
+		TS:            msg.TS,
+		ThreadTS:      msg.ThreadTS,
+		Workspace:     msg.Workspace,
+		Lang:          msg.Lang,
+		Users:         msg.Users,
+		Channels:      msg.Channels,
+		Groups:        msg.Groups,
+		Links:         msg.Links,
+		CorrelationID: msg.CorrelationID,
+	}
+}
+
+// gobotMessage is the message a plugin refers to, the type and the entities are not needed to reply to it.
+func (m Message) gobotMessage() gobot.Message {
+	return gobot.Message{
+		Type:          gobot.ReplyTo,
+		Text:          m.Text,
+		ChannelID:     m.ChannelID,
+		UserID:        m.UserID,
+		TS:            m.TS,
+		ThreadTS:      m.ThreadTS,
+		Workspace:     m.Workspace,
+		Lang:          m.Lang,
+		CorrelationID: m.CorrelationID,
+	}
+}
+
+type ReplyParams struct {
+	Message Message `json:"message"`
+	Text    string  `json:"text"`
+	// InThread replies into the thread of the message
+	InThread bool `json:"in_thread"`
+}
+
+type ReplyResult struct {
+	ChannelID string `json:"channel_id"`
+	TS        string `json:"ts"`
+}
+
+type ReactParams struct {
+	Message Message `json:"message"`
+	Name    string  `json:"name"`
+}
+
+type StartTaskParams struct {
+	Message Message `json:"message"`
+	// CommandLine is the command and its params, e.g. `dist-beta --branch foo`
+	CommandLine string `json:"command_line"`
+}
+
+type StartTaskResult struct {
+	TaskID int `json:"task_id"`
+}
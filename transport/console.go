@@ -0,0 +1,324 @@
+package transport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	consoleBotID   = "UGOBOT"
+	consoleBotName = "gobot"
+
+	consoleHelp = `console commands:
+  /user <name>      talk as @<name>
+  /channel <name>   talk in #<name>
+  /join <name>      join #<name> and talk there
+  /react <ts> <emoji>
+                    react to message <ts> with :<emoji>:
+  /edit <ts> <text> edit message <ts>
+  /delete <ts>      delete message <ts>
+  /dm               talk to the bot in a direct message
+  /thread [<ts>]    talk in the thread of message <ts>, or leave the thread
+  /help             print this help
+messages starting with @gobot mention the bot`
+)
+
+type console struct {
+	in     io.Reader
+	out    io.Writer
+	events chan gobot.Event
+
+	mutex    sync.Mutex
+	users    map[string]string
+	channels map[string]string
+	user     string
+	channel  string
+	thread   string
+	lastTS   int
+	stopped  bool
+	// messages are the messages typed so far by their timestamps, to be edited or deleted
+	messages map[string]gobot.MessageEvent
+}
+
+// NewConsole returns a transport reading messages from in line by line and writing replies to out,
+// it lets handlers and commands be tried locally without slack.
+func NewConsole(in io.Reader, out io.Writer) gobot.Transport {
+	t := &console{
+		in:       in,
+		out:      out,
+		events:   make(chan gobot.Event),
+		users:    map[string]string{consoleBotID: consoleBotName},
+		channels: make(map[string]string),
+		messages: make(map[string]gobot.MessageEvent),
+	}
+	t.user = t.addUser("user")
+	t.channel = t.addChannel("general")
+	return t
+}
+
+func (t *console) Connect() (*gobot.Identity, error) {
+	return &gobot.Identity{UserID: consoleBotID, UserName: consoleBotName}, nil
+}
+
+func (t *console) Run() {
+	t.print(consoleHelp)
+	t.events <- gobot.Event{Type: "connected", Data: &gobot.ConnectedEvent{}}
+
+	scanner := bufio.NewScanner(t.in)
+	for scanner.Scan() && !t.isStopped() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			t.command(line)
+			continue
+		}
+		t.mutex.Lock()
+		t.lastTS++
+		msg := gobot.MessageEvent{
+			ChannelID: t.channel,
+			UserID:    t.user,
+			Text:      mention(line),
+			TS:        strconv.Itoa(t.lastTS),
+			ThreadTS:  t.thread,
+		}
+		t.messages[msg.TS] = msg
+		t.mutex.Unlock()
+		t.events <- gobot.Event{Type: "message", Data: &msg}
+	}
+
+	t.events <- gobot.Event{Type: "disconnected", Data: &gobot.DisconnectedEvent{Intentional: true}}
+	close(t.events)
+}
+
+func (t *console) command(line string) {
+	fields := strings.Fields(line)
+	switch {
+	case fields[0] == "/user" && len(fields) == 2:
+		t.mutex.Lock()
+		t.user = t.addUser(strings.TrimPrefix(fields[1], "@"))
+		t.thread = ""
+		t.mutex.Unlock()
+	case fields[0] == "/channel" && len(fields) == 2:
+		t.mutex.Lock()
+		t.channel = t.addChannel(strings.TrimPrefix(fields[1], "#"))
+		t.thread = ""
+		t.mutex.Unlock()
+	case fields[0] == "/join" && len(fields) == 2:
+		t.mutex.Lock()
+		t.channel = t.addChannel(strings.TrimPrefix(fields[1], "#"))
+		t.thread = ""
+		ev := &gobot.MemberEvent{ChannelID: t.channel, UserID: t.user}
+		t.mutex.Unlock()
+		t.events <- gobot.Event{Type: gobot.EventMemberJoinedChannel, Data: ev}
+	case fields[0] == "/react" && len(fields) == 3:
+		t.mutex.Lock()
+		ev := &gobot.ReactionEvent{
+			UserID:   t.user,
+			Reaction: strings.Trim(fields[2], ":"),
+			Item:     gobot.MessageRef{ChannelID: t.channel, TS: fields[1]},
+		}
+		t.mutex.Unlock()
+		t.events <- gobot.Event{Type: gobot.EventReactionAdded, Data: ev}
+	case fields[0] == "/edit" && len(fields) >= 3:
+		t.mutex.Lock()
+		msg, ok := t.messages[fields[1]]
+		if ok {
+			edited := msg
+			edited.Text = mention(strings.TrimSpace(strings.SplitN(line, " ", 3)[2]))
+			edited.SubType = "message_changed"
+			edited.Edited = true
+			edited.PreviousText = msg.Text
+			t.messages[msg.TS] = edited
+			msg = edited
+		}
+		t.mutex.Unlock()
+		if !ok {
+			t.print("no such message: " + fields[1])
+			return
+		}
+		t.events <- gobot.Event{Type: "message", Data: &msg}
+	case fields[0] == "/delete" && len(fields) == 2:
+		t.mutex.Lock()
+		msg, ok := t.messages[fields[1]]
+		delete(t.messages, fields[1])
+		t.mutex.Unlock()
+		if !ok {
+			t.print("no such message: " + fields[1])
+			return
+		}
+		t.events <- gobot.Event{Type: gobot.EventMessageDeleted, Data: &gobot.MessageDeletedEvent{ChannelID: msg.ChannelID, TS: msg.TS}}
+	case fields[0] == "/dm" && len(fields) == 1:
+		t.mutex.Lock()
+		t.channel = "D" + t.user[1:]
+		t.thread = ""
+		t.mutex.Unlock()
+	case fields[0] == "/thread" && len(fields) <= 2:
+		t.mutex.Lock()
+		t.thread = strings.Join(fields[1:], "")
+		t.mutex.Unlock()
+	default:
+		t.print(consoleHelp)
+	}
+}
+
+// mention turns a leading @gobot into a mention of the bot.
+func mention(line string) string {
+	if line == "@"+consoleBotName || strings.HasPrefix(line, "@"+consoleBotName+" ") {
+		return "<@" + consoleBotID + ">" + line[len(consoleBotName)+1:]
+	}
+	return line
+}
+
+func (t *console) addUser(name string) string {
+	id := "U" + strings.ToUpper(name)
+	t.users[id] = name
+	return id
+}
+
+func (t *console) addChannel(name string) string {
+	id := "C" + strings.ToUpper(name)
+	t.channels[id] = name
+	return id
+}
+
+func (t *console) isStopped() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stopped
+}
+
+func (t *console) print(text string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	fmt.Fprintln(t.out, text)
+}
+
+func (t *console) Disconnect() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stopped = true
+	return nil
+}
+
+func (t *console) IncomingEvents() <-chan gobot.Event {
+	return t.events
+}
+
+func (t *console) SendMessage(msg gobot.OutgoingMessage) (gobot.MessageRef, error) {
+	name, err := t.channelName(msg.ChannelID)
+	if err != nil {
+		return gobot.MessageRef{}, err
+	}
+	if len(msg.ThreadTS) > 0 {
+		name += " > " + msg.ThreadTS
+	}
+
+	t.mutex.Lock()
+	t.lastTS++
+	ts := strconv.Itoa(t.lastTS)
+	t.mutex.Unlock()
+
+	t.print(fmt.Sprintf("[%s] %s (%s): %s", name, consoleBotName, ts, msg.Text))
+	return gobot.MessageRef{ChannelID: msg.ChannelID, TS: ts}, nil
+}
+
+func (t *console) UpdateMessage(ref gobot.MessageRef, msg gobot.OutgoingMessage) error {
+	name, err := t.channelName(ref.ChannelID)
+	if err != nil {
+		return err
+	}
+	t.print(fmt.Sprintf("[%s] %s (%s edited): %s", name, consoleBotName, ref.TS, msg.Text))
+	return nil
+}
+
+func (t *console) DeleteMessage(ref gobot.MessageRef) error {
+	name, err := t.channelName(ref.ChannelID)
+	if err != nil {
+		return err
+	}
+	t.print(fmt.Sprintf("[%s] %s (%s deleted)", name, consoleBotName, ref.TS))
+	return nil
+}
+
+func (t *console) UploadFile(file gobot.FileUpload) (gobot.MessageRef, error) {
+	name, err := t.channelName(file.ChannelID)
+	if err != nil {
+		return gobot.MessageRef{}, err
+	}
+	if len(file.ThreadTS) > 0 {
+		name += " > " + file.ThreadTS
+	}
+	t.print(fmt.Sprintf("[%s] %s uploaded %s (%s):\n%s", name, consoleBotName, file.Filename, file.Title, file.Content))
+	return gobot.MessageRef{ChannelID: file.ChannelID}, nil
+}
+
+func (t *console) AddReaction(name string, ref gobot.MessageRef) error {
+	channelName, err := t.channelName(ref.ChannelID)
+	if err != nil {
+		return err
+	}
+	t.print(fmt.Sprintf("[%s] %s reacted to %s: :%s:", channelName, consoleBotName, ref.TS, name))
+	return nil
+}
+
+func (t *console) RemoveReaction(name string, ref gobot.MessageRef) error {
+	channelName, err := t.channelName(ref.ChannelID)
+	if err != nil {
+		return err
+	}
+	t.print(fmt.Sprintf("[%s] %s removed reaction to %s: :%s:", channelName, consoleBotName, ref.TS, name))
+	return nil
+}
+
+func (t *console) channelName(channelID string) (string, error) {
+	channel, err := t.GetChannel(channelID)
+	if err != nil {
+		return "", err
+	}
+	if channel.IsIM {
+		return "<direct message>", nil
+	}
+	return "#" + channel.Name, nil
+}
+
+func (t *console) GetUser(userID string) (*gobot.User, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	name, ok := t.users[userID]
+	if !ok {
+		return nil, errors.New("user_not_found")
+	}
+	return &gobot.User{ID: userID, Name: name, DisplayName: name}, nil
+}
+
+func (t *console) GetChannel(channelID string) (*gobot.Channel, error) {
+	if strings.HasPrefix(channelID, "D") {
+		return &gobot.Channel{ID: channelID, IsIM: true}, nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	name, ok := t.channels[channelID]
+	if !ok {
+		return nil, errors.New("channel_not_found")
+	}
+	return &gobot.Channel{ID: channelID, Name: name}, nil
+}
+
+func (t *console) ListChannels() ([]gobot.Channel, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var channels []gobot.Channel
+	for id, name := range t.channels {
+		channels = append(channels, gobot.Channel{ID: id, Name: name})
+	}
+	return channels, nil
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+func TestConsole_Run(t *testing.T) {
+	in := strings.NewReader("hello\n/user alice\n/channel deploy\n@gobot ps\n/thread 2\nmore\n/dm\nhelp?\n/join ops\n/react 4 :+1:\n/edit 2 @gobot  ps -a\n/delete 3\n/delete 9\n")
+	tr := NewConsole(in, &bytes.Buffer{})
+	go tr.Run()
+
+	var got []interface{}
+	for ev := range tr.IncomingEvents() {
+		got = append(got, ev.Data)
+	}
+	want := []interface{}{
+		&gobot.ConnectedEvent{},
+		&gobot.MessageEvent{ChannelID: "CGENERAL", UserID: "UUSER", Text: "hello", TS: "1"},
+		&gobot.MessageEvent{ChannelID: "CDEPLOY", UserID: "UALICE", Text: "<@UGOBOT> ps", TS: "2"},
+		&gobot.MessageEvent{ChannelID: "CDEPLOY", UserID: "UALICE", Text: "more", TS: "3", ThreadTS: "2"},
+		&gobot.MessageEvent{ChannelID: "DALICE", UserID: "UALICE", Text: "help?", TS: "4"},
+		&gobot.MemberEvent{ChannelID: "COPS", UserID: "UALICE"},
+		&gobot.ReactionEvent{UserID: "UALICE", Reaction: "+1", Item: gobot.MessageRef{ChannelID: "COPS", TS: "4"}},
+		&gobot.MessageEvent{
+			ChannelID:    "CDEPLOY",
+			UserID:       "UALICE",
+			Text:         "<@UGOBOT>  ps -a",
+			SubType:      "message_changed",
+			TS:           "2",
+			Edited:       true,
+			PreviousText: "<@UGOBOT> ps",
+		},
+		&gobot.MessageDeletedEvent{ChannelID: "CDEPLOY", TS: "3"},
+		&gobot.DisconnectedEvent{Intentional: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	user, err := tr.GetUser("UALICE")
+	if err != nil || user.DisplayName != "alice" {
+		t.Errorf("GetUser() = %v, %v", user, err)
+	}
+}
+
+func TestConsole_SendMessage(t *testing.T) {
+	out := &bytes.Buffer{}
+	tr := NewConsole(strings.NewReader(""), out)
+	msgs := []gobot.OutgoingMessage{
+		{ChannelID: "CGENERAL", Text: "hi"},
+		{ChannelID: "CGENERAL", ThreadTS: "1", Text: "hi"},
+		{ChannelID: "DUSER", Text: "hi"},
+	}
+	for _, msg := range msgs {
+		if _, err := tr.SendMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.UpdateMessage(gobot.MessageRef{ChannelID: "CGENERAL", TS: "1"}, gobot.OutgoingMessage{Text: "bye"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "[#general] gobot (1): hi\n[#general > 1] gobot (2): hi\n[<direct message>] gobot (3): hi\n" +
+		"[#general] gobot (1 edited): bye\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/nlopes/slack/slackevents"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	eventsPath = "/slack/events"
+
+	// eventIDTTL is how long delivered event IDs are remembered, slack retries for a few minutes
+	eventIDTTL = 10 * time.Minute
+)
+
+var (
+	// deliverTimeout is how long a request waits for the bot to take its event,
+	// it fails with 503 afterwards so that slack gets an answer within its 3 seconds and retries later
+	deliverTimeout = 2 * time.Second
+)
+
+type eventsAPI struct {
+	webAPI
+	signingSecret string
+	server        *http.Server
+	events        chan gobot.Event
+	done          chan struct{}
+	closeOnce     sync.Once
+	seen          eventIDs
+}
+
+// NewEventsAPI returns a transport receiving events, slash commands and button clicks from slack
+// through HTTP requests on addr, every request is verified with the app's signing secret.
+func NewEventsAPI(token, signingSecret, addr string, opts ...Option) gobot.Transport {
+	o := newOptions(opts)
+	t := &eventsAPI{
+		webAPI:        webAPI{client: slack.New(token, o.slackOptions()...)},
+		signingSecret: signingSecret,
+		events:        make(chan gobot.Event, 100),
+		done:          make(chan struct{}),
+		seen:          eventIDs{at: make(map[string]time.Time)},
+	}
+	mux := http.NewServeMux()
+	mux.Handle(eventsPath, t)
+	interactions{signingSecret: signingSecret, events: t.events, done: t.done}.register(mux)
+	t.server = &http.Server{Addr: addr, Handler: mux}
+	return t
+}
+
+func (t *eventsAPI) Run() {
+	t.events <- gobot.Event{Type: "connected", Data: &gobot.ConnectedEvent{}}
+	err := t.server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		t.events <- gobot.Event{Type: "error", Data: &gobot.ErrorEvent{Err: err}}
+	}
+	t.events <- gobot.Event{Type: "disconnected", Data: &gobot.DisconnectedEvent{Intentional: err == http.ErrServerClosed}}
+}
+
+func (t *eventsAPI) Disconnect() error {
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+	return t.server.Close()
+}
+
+func (t *eventsAPI) IncomingEvents() <-chan gobot.Event {
+	return t.events
+}
+
+func (t *eventsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := verifyRequest(r, t.signingSecret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// slack retries the events it got no ack for in time, they are delivered once
+	var callback struct {
+		EventID string `json:"event_id"`
+	}
+	_ = json.Unmarshal(body, &callback)
+	if !t.seen.claim(callback.EventID, time.Now()) {
+		return
+	}
+	delivered := false
+	defer func() {
+		if !delivered {
+			t.seen.release(callback.EventID)
+		}
+	}()
+
+	ev, err := slackevents.ParseEvent(json.RawMessage(body), slackevents.OptionNoVerifyToken())
+	if err != nil {
+		if e, ok := convertChannelRenameEvent(body); ok {
+			delivered = deliverEvent(w, t.events, t.done, e)
+			return
+		}
+		// events unknown to the slack library are acknowledged and ignored
+		if ev.Type == "unmarshalling_error" {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	switch ev.Type {
+	case slackevents.URLVerification:
+		verification := ev.Data.(*slackevents.EventsAPIURLVerificationEvent)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(verification.Challenge))
+	case slackevents.CallbackEvent:
+		if e, ok := convertEventsAPIEvent(ev); ok {
+			delivered = deliverEvent(w, t.events, t.done, e)
+		}
+	}
+}
+
+// deliverEvent passes e to the bot, the request fails with 503 when the bot doesn't take it in time or is stopping.
+func deliverEvent(w http.ResponseWriter, events chan<- gobot.Event, done <-chan struct{}, e gobot.Event) bool {
+	timer := time.NewTimer(deliverTimeout)
+	defer timer.Stop()
+	select {
+	case events <- e:
+		return true
+	case <-done:
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	case <-timer.C:
+		http.Error(w, "too many events", http.StatusServiceUnavailable)
+	}
+	return false
+}
+
+// eventIDs are the IDs of the events being delivered or delivered within eventIDTTL.
+type eventIDs struct {
+	mutex sync.Mutex
+	at    map[string]time.Time
+}
+
+// claim marks id as delivered unless it's already, events without ID are always delivered.
+func (ids *eventIDs) claim(id string, now time.Time) bool {
+	if len(id) == 0 {
+		return true
+	}
+	ids.mutex.Lock()
+	defer ids.mutex.Unlock()
+	for seen, at := range ids.at {
+		if now.Sub(at) > eventIDTTL {
+			delete(ids.at, seen)
+		}
+	}
+	if _, ok := ids.at[id]; ok {
+		return false
+	}
+	ids.at[id] = now
+	return true
+}
+
+// release forgets id so that a retry of the event is delivered.
+func (ids *eventIDs) release(id string) {
+	if len(id) == 0 {
+		return
+	}
+	ids.mutex.Lock()
+	defer ids.mutex.Unlock()
+	delete(ids.at, id)
+}
+
+// verifyRequest checks the slack signature of the request and returns its body.
+func verifyRequest(r *http.Request, signingSecret string) ([]byte, error) {
+	verifier, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := verifier.Write(body); err != nil {
+		return nil, err
+	}
+	if err := verifier.Ensure(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
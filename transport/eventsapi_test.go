@@ -0,0 +1,220 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	testSigningSecret = "secret"
+)
+
+func signedRequest(t *testing.T, url, secret, body string) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("v0:" + ts + ":" + body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	return req
+}
+
+func TestEventsAPI_ServeHTTP(t *testing.T) {
+	tr := NewEventsAPI("token", testSigningSecret, "").(*eventsAPI)
+	server := httptest.NewServer(tr)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		secret     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantEvent  interface{}
+	}{
+		{
+			name:       "invalid signature",
+			secret:     "wrong",
+			body:       `{"type":"url_verification","challenge":"abc"}`,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "url verification",
+			secret:     testSigningSecret,
+			body:       `{"type":"url_verification","challenge":"abc"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "abc",
+		},
+		{
+			name:   "message",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":` +
+				`{"type":"message","channel":"C123","user":"U123","text":"<@UBOT> ps","ts":"1.1"}}`,
+			wantStatus: http.StatusOK,
+			wantEvent:  &gobot.MessageEvent{ChannelID: "C123", UserID: "U123", Text: "<@UBOT> ps", TS: "1.1"},
+		},
+		{
+			name:   "message changed",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":{"type":"message","subtype":"message_changed","channel":"C123",` +
+				`"message":{"type":"message","user":"U123","text":"dist-beta --branch foo","ts":"1.1"},` +
+				`"previous_message":{"type":"message","user":"U123","text":"dist-beta --brnch foo","ts":"1.1"},"ts":"1.2"}}`,
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.MessageEvent{
+				ChannelID:    "C123",
+				UserID:       "U123",
+				Text:         "dist-beta --branch foo",
+				SubType:      "message_changed",
+				TS:           "1.1",
+				Edited:       true,
+				PreviousText: "dist-beta --brnch foo",
+			},
+		},
+		{
+			name:   "message deleted",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":{"type":"message","subtype":"message_deleted","channel":"C123",` +
+				`"previous_message":{"type":"message","user":"U123","text":"dist-beta","ts":"1.1"},"ts":"1.2"}}`,
+			wantStatus: http.StatusOK,
+			wantEvent:  &gobot.MessageDeletedEvent{ChannelID: "C123", TS: "1.1"},
+		},
+		{
+			name:   "reaction added",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":{"type":"reaction_added","user":"U123","reaction":"eyes",` +
+				`"item_user":"U456","item":{"type":"message","channel":"C123","ts":"1.1"}}}`,
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.ReactionEvent{
+				UserID:     "U123",
+				Reaction:   "eyes",
+				ItemUserID: "U456",
+				Item:       gobot.MessageRef{ChannelID: "C123", TS: "1.1"},
+			},
+		},
+		{
+			name:   "member joined channel",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":` +
+				`{"type":"member_joined_channel","user":"U123","channel":"C123"}}`,
+			wantStatus: http.StatusOK,
+			wantEvent:  &gobot.MemberEvent{ChannelID: "C123", UserID: "U123"},
+		},
+		{
+			name:   "channel rename",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":` +
+				`{"type":"channel_rename","channel":{"id":"C123","name":"random","created":1}}}`,
+			wantStatus: http.StatusOK,
+			wantEvent:  &gobot.ChannelRenameEvent{ChannelID: "C123", Name: "random"},
+		},
+		{
+			name:   "user change",
+			secret: testSigningSecret,
+			body: `{"type":"event_callback","event":` +
+				`{"type":"user_change","user":{"id":"U123","name":"taro","profile":` +
+				`{"display_name":"Taro","status_text":"lunch","status_emoji":":ramen:"}}}}`,
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.UserChangeEvent{User: gobot.User{
+				ID:          "U123",
+				Name:        "taro",
+				DisplayName: "Taro",
+				StatusText:  "lunch",
+				StatusEmoji: ":ramen:",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := http.DefaultClient.Do(signedRequest(t, server.URL, tt.secret, tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			if len(tt.wantBody) > 0 {
+				body, _ := ioutil.ReadAll(res.Body)
+				if string(body) != tt.wantBody {
+					t.Errorf("body = %v, want %v", string(body), tt.wantBody)
+				}
+			}
+			if tt.wantEvent != nil {
+				select {
+				case ev := <-tr.IncomingEvents():
+					if !reflect.DeepEqual(ev.Data, tt.wantEvent) {
+						t.Errorf("event = %+v, want %+v", ev.Data, tt.wantEvent)
+					}
+				case <-time.After(time.Second):
+					t.Error("no event received")
+				}
+			}
+		})
+	}
+}
+
+func TestEventsAPI_Retries(t *testing.T) {
+	defer func(d time.Duration) { deliverTimeout = d }(deliverTimeout)
+	deliverTimeout = 10 * time.Millisecond
+
+	tr := NewEventsAPI("token", testSigningSecret, "").(*eventsAPI)
+	tr.events = make(chan gobot.Event, 1)
+	server := httptest.NewServer(tr)
+	defer server.Close()
+
+	message := func(id string) string {
+		return `{"type":"event_callback","event_id":"` + id + `","event":` +
+			`{"type":"message","channel":"C123","user":"U123","text":"ps","ts":"1.1"}}`
+	}
+	tests := []struct {
+		name string
+		body string
+		// busy fills the buffer of the events, the bot takes none
+		busy       bool
+		wantStatus int
+		wantEvents int
+	}{
+		{name: "delivered", body: message("Ev1"), wantStatus: http.StatusOK, wantEvents: 1},
+		{name: "retry of delivered", body: message("Ev1"), wantStatus: http.StatusOK},
+		{name: "bot too busy", body: message("Ev2"), busy: true, wantStatus: http.StatusServiceUnavailable},
+		{name: "retry of undelivered", body: message("Ev2"), wantStatus: http.StatusOK, wantEvents: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.busy {
+				tr.events <- gobot.Event{Type: "busy"}
+			}
+			res, err := http.DefaultClient.Do(signedRequest(t, server.URL, testSigningSecret, tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			got := 0
+			for len(tr.events) > 0 {
+				if ev := <-tr.events; ev.Type == "message" {
+					got++
+				}
+			}
+			if got != tt.wantEvents {
+				t.Errorf("events = %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/nlopes/slack"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	commandsPath     = "/slack/commands"
+	interactionsPath = "/slack/interactions"
+
+	// buttonActionID identifies the buttons of rich messages
+	buttonActionID = "gobot_button"
+)
+
+// interactions receives slash commands and button clicks and turns them into messages addressed to the bot.
+type interactions struct {
+	signingSecret string
+	events        chan<- gobot.Event
+	// done is closed when the transport is stopping
+	done <-chan struct{}
+}
+
+func (h interactions) register(mux *http.ServeMux) {
+	mux.HandleFunc(commandsPath, h.serveCommand)
+	mux.HandleFunc(interactionsPath, h.serveInteraction)
+}
+
+// newInteractionsServer returns the server receiving interactions if the transport is configured so, it returns nil otherwise.
+func newInteractionsServer(o options, events chan<- gobot.Event) *http.Server {
+	if len(o.interactionsAddr) == 0 {
+		return nil
+	}
+	mux := http.NewServeMux()
+	interactions{signingSecret: o.signingSecret, events: events}.register(mux)
+	return &http.Server{Addr: o.interactionsAddr, Handler: mux}
+}
+
+// runInteractionsServer serves until the server is closed, failures are reported as error events.
+func runInteractionsServer(server *http.Server, events chan<- gobot.Event) {
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		events <- gobot.Event{Type: "error", Data: &gobot.ErrorEvent{Err: err}}
+	}
+}
+
+func (h interactions) serveCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := verifyRequest(r, h.signingSecret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	cmd, err := slack.SlashCommandParse(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !deliverEvent(w, h.events, h.done, convertSlashCommand(cmd)) {
+		return
+	}
+
+	// keep the command visible in the channel
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"response_type":"in_channel"}`))
+}
+
+func (h interactions) serveInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := verifyRequest(r, h.signingSecret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal([]byte(form.Get("payload")), &callback); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if e, ok := convertInteraction(callback); ok {
+		deliverEvent(w, h.events, h.done, e)
+	}
+}
+
+func convertSlashCommand(cmd slack.SlashCommand) gobot.Event {
+	return gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+		ChannelID: cmd.ChannelID,
+		UserID:    cmd.UserID,
+		Text:      cmd.Text,
+		Mention:   true,
+		Action:    &gobot.Action{Command: cmd.Command, ResponseURL: cmd.ResponseURL},
+	}}
+}
+
+// convertInteraction converts a button click into a message with the value of the button,
+// posted in the thread of the message the button belongs to. Other interactions are ignored.
+func convertInteraction(callback slack.InteractionCallback) (gobot.Event, bool) {
+	action := &gobot.Action{ResponseURL: callback.ResponseURL}
+	var threadTS string
+	switch callback.Type {
+	case slack.InteractionTypeBlockActions:
+		if len(callback.ActionCallback.BlockActions) == 0 {
+			return gobot.Event{}, false
+		}
+		a := callback.ActionCallback.BlockActions[0]
+		action.ID = a.ActionID
+		action.Value = a.Value
+		action.Message = gobot.MessageRef{ChannelID: callback.Channel.ID, TS: callback.Message.Timestamp}
+		threadTS = callback.Message.ThreadTimestamp
+	case slack.InteractionTypeInteractionMessage:
+		if len(callback.ActionCallback.AttachmentActions) == 0 {
+			return gobot.Event{}, false
+		}
+		a := callback.ActionCallback.AttachmentActions[0]
+		action.ID = a.Name
+		action.Value = a.Value
+		action.Message = gobot.MessageRef{ChannelID: callback.Channel.ID, TS: callback.MessageTs}
+		threadTS = callback.OriginalMessage.ThreadTimestamp
+	default:
+		return gobot.Event{}, false
+	}
+	return gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+		ChannelID: callback.Channel.ID,
+		UserID:    callback.User.ID,
+		Text:      action.Value,
+		ThreadTS:  threadTS,
+		Mention:   true,
+		Action:    action,
+	}}, true
+}
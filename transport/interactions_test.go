@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+func TestInteractions(t *testing.T) {
+	tr := NewEventsAPI("token", testSigningSecret, "").(*eventsAPI)
+	server := httptest.NewServer(tr.server.Handler)
+	defer server.Close()
+
+	command := url.Values{
+		"command":      {"/gobot"},
+		"text":         {"dist-beta --branch x"},
+		"channel_id":   {"C123"},
+		"user_id":      {"U123"},
+		"response_url": {"https://hooks.slack.com/commands/1"},
+	}
+	blockActions := url.Values{"payload": {`{"type":"block_actions","user":{"id":"U123"},"channel":{"id":"C123"},` +
+		`"message":{"ts":"1.2","thread_ts":"1.1"},` +
+		`"actions":[{"action_id":"gobot_button_0","block_id":"b","type":"button","value":"kill 12"}]}`}}
+	attachmentActions := url.Values{"payload": {`{"type":"interactive_message","callback_id":"gobot_button",` +
+		`"user":{"id":"U123"},"channel":{"id":"C123"},"message_ts":"1.2",` +
+		`"actions":[{"name":"gobot_button","type":"button","value":"dist-beta"}]}`}}
+
+	tests := []struct {
+		name       string
+		path       string
+		secret     string
+		body       string
+		wantStatus int
+		wantEvent  *gobot.MessageEvent
+	}{
+		{
+			name:       "invalid signature",
+			path:       commandsPath,
+			secret:     "wrong",
+			body:       command.Encode(),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "slash command",
+			path:       commandsPath,
+			secret:     testSigningSecret,
+			body:       command.Encode(),
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.MessageEvent{
+				ChannelID: "C123",
+				UserID:    "U123",
+				Text:      "dist-beta --branch x",
+				Mention:   true,
+				Action:    &gobot.Action{Command: "/gobot", ResponseURL: "https://hooks.slack.com/commands/1"},
+			},
+		},
+		{
+			name:       "block button",
+			path:       interactionsPath,
+			secret:     testSigningSecret,
+			body:       blockActions.Encode(),
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.MessageEvent{
+				ChannelID: "C123",
+				UserID:    "U123",
+				Text:      "kill 12",
+				ThreadTS:  "1.1",
+				Mention:   true,
+				Action: &gobot.Action{
+					ID:      "gobot_button_0",
+					Value:   "kill 12",
+					Message: gobot.MessageRef{ChannelID: "C123", TS: "1.2"},
+				},
+			},
+		},
+		{
+			name:       "attachment button",
+			path:       interactionsPath,
+			secret:     testSigningSecret,
+			body:       attachmentActions.Encode(),
+			wantStatus: http.StatusOK,
+			wantEvent: &gobot.MessageEvent{
+				ChannelID: "C123",
+				UserID:    "U123",
+				Text:      "dist-beta",
+				Mention:   true,
+				Action: &gobot.Action{
+					ID:      "gobot_button",
+					Value:   "dist-beta",
+					Message: gobot.MessageRef{ChannelID: "C123", TS: "1.2"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := signedRequest(t, server.URL+tt.path, tt.secret, tt.body)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantEvent != nil {
+				select {
+				case ev := <-tr.IncomingEvents():
+					if !reflect.DeepEqual(ev.Data, tt.wantEvent) {
+						t.Errorf("event = %+v, want %+v", ev.Data, tt.wantEvent)
+					}
+				case <-time.After(time.Second):
+					t.Error("no event received")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"net/http"
+
+	"github.com/nlopes/slack"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+type rtm struct {
+	webAPI
+	rtm          *slack.RTM
+	events       chan gobot.Event
+	interactions *http.Server
+}
+
+// NewRTM returns a transport talking to slack through the RTM API.
+func NewRTM(token string, opts ...Option) gobot.Transport {
+	o := newOptions(opts)
+	client := slack.New(token, o.slackOptions()...)
+	t := &rtm{
+		webAPI: webAPI{client: client},
+		rtm:    client.NewRTM(),
+		events: make(chan gobot.Event),
+	}
+	t.interactions = newInteractionsServer(o, t.events)
+	return t
+}
+
+func (t *rtm) Run() {
+	if t.interactions != nil {
+		go runInteractionsServer(t.interactions, t.events)
+	}
+	go t.rtm.ManageConnection()
+	for ev := range t.rtm.IncomingEvents {
+		switch data := ev.Data.(type) {
+		case *slack.ConnectedEvent:
+			t.events <- gobot.Event{Type: "connected", Data: &gobot.ConnectedEvent{}}
+		case *slack.DisconnectedEvent:
+			t.events <- gobot.Event{Type: "disconnected", Data: &gobot.DisconnectedEvent{Intentional: data.Intentional}}
+		case *slack.MessageEvent:
+			t.events <- convertMessageEvent(data)
+		default:
+			if e, ok := convertSlackEvent(data); ok {
+				t.events <- e
+			}
+		}
+	}
+}
+
+func (t *rtm) Disconnect() error {
+	if t.interactions != nil {
+		_ = t.interactions.Close()
+	}
+	return t.rtm.Disconnect()
+}
+
+func (t *rtm) IncomingEvents() <-chan gobot.Event {
+	return t.events
+}
@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+	"github.com/nlopes/slack/slackevents"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	maxReconnectInterval = time.Minute
+	// dialTimeout bounds the request opening a connection, a stuck one would keep the transport from reconnecting
+	dialTimeout = 30 * time.Second
+)
+
+type socketMode struct {
+	webAPI
+	appToken string
+	apiURL   string
+	client   *http.Client
+	events   chan gobot.Event
+
+	mutex   sync.Mutex
+	conn    *websocket.Conn
+	stopped bool
+}
+
+type socketModeEnvelope struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Type       string          `json:"type"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// NewSocketMode returns a transport receiving events, slash commands and button clicks from slack through a socket mode websocket,
+// the connection is opened with the app-level token (xapp-...) and messages are sent with the bot token.
+func NewSocketMode(token, appToken string, opts ...Option) gobot.Transport {
+	o := newOptions(opts)
+	return &socketMode{
+		webAPI:   webAPI{client: slack.New(token, o.slackOptions()...)},
+		appToken: appToken,
+		apiURL:   o.apiURL,
+		client:   &http.Client{Timeout: dialTimeout},
+		events:   make(chan gobot.Event),
+	}
+}
+
+func (t *socketMode) Run() {
+	interval := time.Second
+	for !t.isStopped() {
+		conn, err := t.dial()
+		if err != nil {
+			t.events <- gobot.Event{Type: "error", Data: &gobot.ErrorEvent{Err: err}}
+			time.Sleep(interval)
+			if interval *= 2; interval > maxReconnectInterval {
+				interval = maxReconnectInterval
+			}
+			continue
+		}
+		interval = time.Second
+
+		t.events <- gobot.Event{Type: "connected", Data: &gobot.ConnectedEvent{}}
+		if err := t.receive(conn); err != nil && !t.isStopped() {
+			t.events <- gobot.Event{Type: "error", Data: &gobot.ErrorEvent{Err: err}}
+		}
+		conn.Close()
+		t.events <- gobot.Event{Type: "disconnected", Data: &gobot.DisconnectedEvent{Intentional: t.isStopped()}}
+	}
+}
+
+func (t *socketMode) isStopped() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.stopped
+}
+
+// dial asks slack for a websocket url and connects to it.
+func (t *socketMode) dial() (*websocket.Conn, error) {
+	req, err := http.NewRequest(http.MethodPost, t.apiURL+"apps.connections.open", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.appToken)
+	res, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var opened struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		URL   string `json:"url"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&opened); err != nil {
+		return nil, err
+	}
+	if !opened.OK {
+		return nil, fmt.Errorf("fail to open socket mode connection: %s", opened.Error)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(opened.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.stopped {
+		conn.Close()
+		return nil, errors.New("socket mode transport stopped")
+	}
+	t.conn = conn
+	return conn, nil
+}
+
+// receive acknowledges and dispatches envelopes until slack asks to reconnect or the connection breaks.
+func (t *socketMode) receive(conn *websocket.Conn) error {
+	for {
+		var envelope socketModeEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			return err
+		}
+		if len(envelope.EnvelopeID) > 0 {
+			if err := conn.WriteJSON(map[string]string{"envelope_id": envelope.EnvelopeID}); err != nil {
+				return err
+			}
+		}
+
+		switch envelope.Type {
+		case "disconnect":
+			return nil
+		case "events_api":
+			ev, err := slackevents.ParseEvent(envelope.Payload, slackevents.OptionNoVerifyToken())
+			if err != nil {
+				if e, ok := convertChannelRenameEvent(envelope.Payload); ok {
+					t.events <- e
+				}
+				continue
+			}
+			if e, ok := convertEventsAPIEvent(ev); ok {
+				t.events <- e
+			}
+		case "slash_commands":
+			var cmd slack.SlashCommand
+			if err := json.Unmarshal(envelope.Payload, &cmd); err != nil {
+				continue
+			}
+			t.events <- convertSlashCommand(cmd)
+		case "interactive":
+			var callback slack.InteractionCallback
+			if err := json.Unmarshal(envelope.Payload, &callback); err != nil {
+				continue
+			}
+			if e, ok := convertInteraction(callback); ok {
+				t.events <- e
+			}
+		}
+	}
+}
+
+func (t *socketMode) Disconnect() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.stopped = true
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
+
+func (t *socketMode) IncomingEvents() <-chan gobot.Event {
+	return t.events
+}
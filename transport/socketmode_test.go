@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+// fakeSlack serves apps.connections.open and a socket mode websocket sending the given envelopes.
+func fakeSlack(t *testing.T, envelopes []string, acks chan<- string) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xapp-token" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"url":"ws` + strings.TrimPrefix(server.URL, "http") + `/link"}`))
+	})
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for _, e := range envelopes {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(e)); err != nil {
+				return
+			}
+			var ack struct {
+				EnvelopeID string `json:"envelope_id"`
+			}
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+			acks <- ack.EnvelopeID
+		}
+		// keep the connection open until the client disconnects
+		conn.ReadMessage()
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestSocketMode_Run(t *testing.T) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type": "event_callback",
+		"event": map[string]string{
+			"type": "message", "channel": "C123", "user": "U123", "text": "hello", "ts": "1.1",
+		},
+	})
+	envelopes := []string{`{"envelope_id":"E1","type":"events_api","payload":` + string(payload) + `}`}
+	acks := make(chan string, 1)
+	server := fakeSlack(t, envelopes, acks)
+	defer server.Close()
+
+	tr := NewSocketMode("xoxb-token", "xapp-token", OptionAPIURL(server.URL+"/api/"))
+	go tr.Run()
+	defer tr.Disconnect()
+
+	want := []interface{}{
+		&gobot.ConnectedEvent{},
+		&gobot.MessageEvent{ChannelID: "C123", UserID: "U123", Text: "hello", TS: "1.1"},
+	}
+	for _, w := range want {
+		select {
+		case ev := <-tr.IncomingEvents():
+			if !reflect.DeepEqual(ev.Data, w) {
+				t.Errorf("event = %+v, want %+v", ev.Data, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no event received, want %+v", w)
+		}
+	}
+	select {
+	case id := <-acks:
+		if id != "E1" {
+			t.Errorf("ack = %v, want E1", id)
+		}
+	case <-time.After(time.Second):
+		t.Error("envelope not acknowledged")
+	}
+}
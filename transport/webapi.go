@@ -0,0 +1,427 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/nlopes/slack/slackevents"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+const (
+	subTypeMessageChanged = "message_changed"
+	subTypeMessageDeleted = "message_deleted"
+)
+
+type Option func(*options)
+
+type options struct {
+	apiURL string
+
+	signingSecret    string
+	interactionsAddr string
+}
+
+// OptionAPIURL points the transport at another slack api, e.g. a local fake server.
+func OptionAPIURL(u string) Option {
+	return func(o *options) {
+		o.apiURL = u
+	}
+}
+
+// OptionInteractions makes the RTM transport receive slash commands and button clicks through HTTP requests on addr,
+// every request is verified with the app's signing secret. The other slack transports receive them anyway.
+func OptionInteractions(signingSecret, addr string) Option {
+	return func(o *options) {
+		o.signingSecret = signingSecret
+		o.interactionsAddr = addr
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{apiURL: slack.APIURL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o options) slackOptions() []slack.Option {
+	return []slack.Option{slack.OptionAPIURL(o.apiURL)}
+}
+
+// webAPI implements the part of gobot.Transport served by the slack web API,
+// it is shared by all slack transports.
+type webAPI struct {
+	client *slack.Client
+}
+
+func (api webAPI) Connect() (*gobot.Identity, error) {
+	res, err := api.client.AuthTest()
+	if err != nil {
+		return nil, err
+	}
+	return &gobot.Identity{UserID: res.UserID, UserName: res.User}, nil
+}
+
+func (api webAPI) SendMessage(msg gobot.OutgoingMessage) (gobot.MessageRef, error) {
+	options := messageOptions(msg)
+	if len(msg.ThreadTS) > 0 {
+		options = append(options, slack.MsgOptionTS(msg.ThreadTS))
+	}
+	channelID, ts, err := api.client.PostMessage(msg.ChannelID, options...)
+	if limited, ok := err.(*slack.RateLimitedError); ok {
+		return gobot.MessageRef{}, &gobot.RateLimitedError{RetryAfter: limited.RetryAfter}
+	}
+	if err != nil {
+		return gobot.MessageRef{}, err
+	}
+	return gobot.MessageRef{ChannelID: channelID, TS: ts}, nil
+}
+
+// UploadFile shares the file as a text snippet, the ref has no timestamp as files aren't posted as messages.
+func (api webAPI) UploadFile(file gobot.FileUpload) (gobot.MessageRef, error) {
+	_, err := api.client.UploadFile(slack.FileUploadParameters{
+		Content:         file.Content,
+		Filetype:        "text",
+		Filename:        file.Filename,
+		Title:           file.Title,
+		Channels:        []string{file.ChannelID},
+		ThreadTimestamp: file.ThreadTS,
+	})
+	if err != nil {
+		return gobot.MessageRef{}, err
+	}
+	return gobot.MessageRef{ChannelID: file.ChannelID}, nil
+}
+
+func (api webAPI) UpdateMessage(ref gobot.MessageRef, msg gobot.OutgoingMessage) error {
+	_, _, _, err := api.client.UpdateMessage(ref.ChannelID, ref.TS, messageOptions(msg)...)
+	return err
+}
+
+func (api webAPI) DeleteMessage(ref gobot.MessageRef) error {
+	_, _, err := api.client.DeleteMessage(ref.ChannelID, ref.TS)
+	return err
+}
+
+func (api webAPI) AddReaction(name string, ref gobot.MessageRef) error {
+	return api.client.AddReaction(name, slack.NewRefToMessage(ref.ChannelID, ref.TS))
+}
+
+func (api webAPI) RemoveReaction(name string, ref gobot.MessageRef) error {
+	return api.client.RemoveReaction(name, slack.NewRefToMessage(ref.ChannelID, ref.TS))
+}
+
+func messageOptions(msg gobot.OutgoingMessage) []slack.MsgOption {
+	options := []slack.MsgOption{slack.MsgOptionText(msg.Text, false), slack.MsgOptionAsUser(true)}
+	if msg.Rich != nil {
+		options = append(options, richMessageOption(*msg.Rich))
+	}
+	return options
+}
+
+func (api webAPI) GetUser(userID string) (*gobot.User, error) {
+	u, err := api.client.GetUserInfo(userID)
+	if err != nil {
+		return nil, err
+	}
+	user := convertUser(*u)
+	return &user, nil
+}
+
+func (api webAPI) GetChannel(channelID string) (*gobot.Channel, error) {
+	c, err := api.client.GetConversationInfo(channelID, false)
+	if err != nil {
+		return nil, err
+	}
+	return &gobot.Channel{ID: c.ID, Name: c.Name, IsIM: c.IsIM}, nil
+}
+
+func (api webAPI) ListChannels() ([]gobot.Channel, error) {
+	var channels []gobot.Channel
+	params := &slack.GetConversationsParameters{
+		ExcludeArchived: "true",
+		Limit:           200,
+		Types:           []string{"public_channel", "private_channel", "im"},
+	}
+	for {
+		cc, cursor, err := api.client.GetConversations(params)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cc {
+			channels = append(channels, gobot.Channel{ID: c.ID, Name: c.Name, IsIM: c.IsIM})
+		}
+		if len(cursor) == 0 {
+			return channels, nil
+		}
+		params.Cursor = cursor
+	}
+}
+
+// richMessageOption renders colored messages as attachments and the others as blocks.
+func richMessageOption(m gobot.RichMessage) slack.MsgOption {
+	if len(m.Color) > 0 {
+		var attachments []slack.Attachment
+		for _, s := range m.Sections {
+			a := slack.Attachment{
+				Color:      m.Color,
+				Fallback:   gobot.RichMessage{Sections: []gobot.Section{s}}.PlainText(),
+				Title:      s.Title,
+				Text:       s.Text,
+				Footer:     s.Context,
+				MarkdownIn: []string{"text", "fields"},
+			}
+			for _, f := range s.Fields {
+				a.Fields = append(a.Fields, slack.AttachmentField{Title: f.Title, Value: f.Value, Short: true})
+			}
+			attachments = append(attachments, a)
+		}
+		if len(m.Buttons) > 0 {
+			a := slack.Attachment{Color: m.Color, CallbackID: buttonActionID, Fallback: " "}
+			for _, b := range m.Buttons {
+				a.Actions = append(a.Actions, slack.AttachmentAction{
+					Name:  buttonActionID,
+					Text:  b.Text,
+					Type:  "button",
+					Value: b.Value,
+					Style: b.Style,
+				})
+			}
+			attachments = append(attachments, a)
+		}
+		return slack.MsgOptionAttachments(attachments...)
+	}
+
+	var blocks []slack.Block
+	for _, s := range m.Sections {
+		var lines []string
+		if len(s.Title) > 0 {
+			lines = append(lines, "*"+s.Title+"*")
+		}
+		if len(s.Text) > 0 {
+			lines = append(lines, s.Text)
+		}
+		var text *slack.TextBlockObject
+		if len(lines) > 0 {
+			text = slack.NewTextBlockObject(slack.MarkdownType, strings.Join(lines, "\n"), false, false)
+		}
+		var fields []*slack.TextBlockObject
+		for _, f := range s.Fields {
+			fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType, "*"+f.Title+"*\n"+f.Value, false, false))
+		}
+		if text != nil || len(fields) > 0 {
+			blocks = append(blocks, slack.NewSectionBlock(text, fields, nil))
+		}
+		if len(s.Context) > 0 {
+			blocks = append(blocks, slack.NewContextBlock("", slack.NewTextBlockObject(slack.MarkdownType, s.Context, false, false)))
+		}
+	}
+	if len(m.Buttons) > 0 {
+		var elements []slack.BlockElement
+		for i, b := range m.Buttons {
+			button := slack.NewButtonBlockElement(fmt.Sprintf("%s_%d", buttonActionID, i), b.Value,
+				slack.NewTextBlockObject(slack.PlainTextType, b.Text, false, false))
+			button.Style = slack.Style(b.Style)
+			elements = append(elements, button)
+		}
+		blocks = append(blocks, slack.NewActionBlock("", elements...))
+	}
+	return slack.MsgOptionBlocks(blocks...)
+}
+
+// convertEventsAPIEvent converts an events API callback into a bot event,
+// it returns false for events the bot doesn't care about.
+func convertEventsAPIEvent(ev slackevents.EventsAPIEvent) (gobot.Event, bool) {
+	switch data := ev.InnerEvent.Data.(type) {
+	case *slackevents.MessageEvent:
+		switch {
+		case data.SubType == subTypeMessageChanged && data.Message != nil:
+			msg := &gobot.MessageEvent{
+				ChannelID: data.Channel,
+				UserID:    data.Message.User,
+				BotID:     data.Message.BotID,
+				Text:      data.Message.Text,
+				SubType:   data.SubType,
+				TS:        data.Message.TimeStamp,
+				ThreadTS:  data.Message.ThreadTimeStamp,
+				Edited:    true,
+			}
+			if data.PreviousMessage != nil {
+				msg.PreviousText = data.PreviousMessage.Text
+			}
+			return gobot.Event{Type: "message", Data: msg}, true
+		case data.SubType == subTypeMessageDeleted && data.PreviousMessage != nil:
+			return gobot.Event{Type: gobot.EventMessageDeleted, Data: &gobot.MessageDeletedEvent{
+				ChannelID: data.Channel,
+				TS:        data.PreviousMessage.TimeStamp,
+			}}, true
+		}
+		return gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+			ChannelID: data.Channel,
+			UserID:    data.User,
+			BotID:     data.BotID,
+			Text:      data.Text,
+			SubType:   data.SubType,
+			TS:        data.TimeStamp,
+			ThreadTS:  data.ThreadTimeStamp,
+		}}, true
+	case *slackevents.MemberJoinedChannelEvent:
+		return gobot.Event{Type: gobot.EventMemberJoinedChannel, Data: &gobot.MemberEvent{ChannelID: data.Channel, UserID: data.User}}, true
+	case *slackevents.PinAddedEvent:
+		return gobot.Event{Type: gobot.EventPinAdded, Data: &gobot.PinEvent{
+			ChannelID: data.Channel,
+			UserID:    data.User,
+			Item:      eventsAPIPinnedMessage(data.Channel, data.Item),
+		}}, true
+	case *slackevents.PinRemovedEvent:
+		return gobot.Event{Type: gobot.EventPinRemoved, Data: &gobot.PinEvent{
+			ChannelID: data.Channel,
+			UserID:    data.User,
+			Item:      eventsAPIPinnedMessage(data.Channel, data.Item),
+		}}, true
+	default:
+		// the events API falls back to the RTM types for the other events
+		return convertSlackEvent(data)
+	}
+}
+
+// convertMessageEvent converts a message received through RTM, edits and deletions included.
+func convertMessageEvent(data *slack.MessageEvent) gobot.Event {
+	switch {
+	case data.SubType == subTypeMessageChanged && data.SubMessage != nil:
+		msg := &gobot.MessageEvent{
+			ChannelID: data.Channel,
+			UserID:    data.SubMessage.User,
+			BotID:     data.SubMessage.BotID,
+			Text:      data.SubMessage.Text,
+			SubType:   data.SubType,
+			TS:        data.SubMessage.Timestamp,
+			ThreadTS:  data.SubMessage.ThreadTimestamp,
+			Edited:    true,
+		}
+		if data.PreviousMessage != nil {
+			msg.PreviousText = data.PreviousMessage.Text
+		}
+		return gobot.Event{Type: "message", Data: msg}
+	case data.SubType == subTypeMessageDeleted:
+		return gobot.Event{Type: gobot.EventMessageDeleted, Data: &gobot.MessageDeletedEvent{
+			ChannelID: data.Channel,
+			TS:        data.DeletedTimestamp,
+		}}
+	}
+	return gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+		ChannelID: data.Channel,
+		UserID:    data.User,
+		BotID:     data.BotID,
+		Text:      data.Text,
+		SubType:   data.SubType,
+		TS:        data.Timestamp,
+		ThreadTS:  data.ThreadTimestamp,
+	}}
+}
+
+// convertSlackEvent converts the slack events passed to event handlers.
+func convertSlackEvent(data interface{}) (gobot.Event, bool) {
+	switch data := data.(type) {
+	case *slack.ReactionAddedEvent:
+		return gobot.Event{Type: gobot.EventReactionAdded, Data: &gobot.ReactionEvent{
+			UserID:     data.User,
+			Reaction:   data.Reaction,
+			ItemUserID: data.ItemUser,
+			Item:       gobot.MessageRef{ChannelID: data.Item.Channel, TS: data.Item.Timestamp},
+		}}, true
+	case *slack.ReactionRemovedEvent:
+		return gobot.Event{Type: gobot.EventReactionRemoved, Data: &gobot.ReactionEvent{
+			UserID:     data.User,
+			Reaction:   data.Reaction,
+			ItemUserID: data.ItemUser,
+			Item:       gobot.MessageRef{ChannelID: data.Item.Channel, TS: data.Item.Timestamp},
+		}}, true
+	case *slack.MemberJoinedChannelEvent:
+		return gobot.Event{Type: gobot.EventMemberJoinedChannel, Data: &gobot.MemberEvent{ChannelID: data.Channel, UserID: data.User}}, true
+	case *slack.MemberLeftChannelEvent:
+		return gobot.Event{Type: gobot.EventMemberLeftChannel, Data: &gobot.MemberEvent{ChannelID: data.Channel, UserID: data.User}}, true
+	case *slack.ChannelCreatedEvent:
+		return gobot.Event{Type: gobot.EventChannelCreated, Data: &gobot.ChannelCreatedEvent{
+			ChannelID: data.Channel.ID,
+			Name:      data.Channel.Name,
+			CreatorID: data.Channel.Creator,
+		}}, true
+	case *slack.ChannelRenameEvent:
+		return gobot.Event{Type: gobot.EventChannelRename, Data: &gobot.ChannelRenameEvent{
+			ChannelID: data.Channel.ID,
+			Name:      data.Channel.Name,
+		}}, true
+	case *slack.UserChangeEvent:
+		return gobot.Event{Type: gobot.EventUserChange, Data: &gobot.UserChangeEvent{User: convertUser(data.User)}}, true
+	case *slack.IMCreatedEvent:
+		return gobot.Event{Type: gobot.EventIMCreated, Data: &gobot.IMCreatedEvent{
+			ChannelID: data.Channel.ID,
+			UserID:    data.User,
+		}}, true
+	case *slack.PinAddedEvent:
+		return gobot.Event{Type: gobot.EventPinAdded, Data: &gobot.PinEvent{
+			ChannelID: data.Channel,
+			UserID:    data.User,
+			Item:      pinnedMessage(data.Channel, data.Item.Timestamp, data.Item.Message),
+		}}, true
+	case *slack.PinRemovedEvent:
+		return gobot.Event{Type: gobot.EventPinRemoved, Data: &gobot.PinEvent{
+			ChannelID: data.Channel,
+			UserID:    data.User,
+			Item:      pinnedMessage(data.Channel, data.Item.Timestamp, data.Item.Message),
+		}}, true
+	}
+	return gobot.Event{}, false
+}
+
+func pinnedMessage(channelID, ts string, msg *slack.Message) gobot.MessageRef {
+	if msg != nil && len(ts) == 0 {
+		ts = msg.Timestamp
+	}
+	return gobot.MessageRef{ChannelID: channelID, TS: ts}
+}
+
+func convertUser(u slack.User) gobot.User {
+	return gobot.User{
+		ID:          u.ID,
+		Name:        u.Name,
+		DisplayName: u.Profile.DisplayName,
+		StatusText:  u.Profile.StatusText,
+		StatusEmoji: u.Profile.StatusEmoji,
+	}
+}
+
+func eventsAPIPinnedMessage(channelID string, item slackevents.Item) gobot.MessageRef {
+	ts := item.Timestamp
+	if item.Message != nil && len(ts) == 0 {
+		ts = item.Message.Timestamp
+	}
+	return gobot.MessageRef{ChannelID: channelID, TS: ts}
+}
+
+// convertChannelRenameEvent converts a channel_rename callback, body is the whole callback.
+// The slack library fails to decode it as it expects the creation time of the channel to be a string.
+func convertChannelRenameEvent(body []byte) (gobot.Event, bool) {
+	var callback struct {
+		Event struct {
+			Type    string `json:"type"`
+			Channel struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channel"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal(body, &callback); err != nil || callback.Event.Type != "channel_rename" {
+		return gobot.Event{}, false
+	}
+	return gobot.Event{Type: gobot.EventChannelRename, Data: &gobot.ChannelRenameEvent{
+		ChannelID: callback.Event.Channel.ID,
+		Name:      callback.Event.Channel.Name,
+	}}, true
+}
@@ -0,0 +1,133 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/nlopes/slack"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+func TestWebAPI_SendMessage(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`{"ok":true,"channel":"C123","ts":"1.1"}`))
+	}))
+	defer server.Close()
+	api := webAPI{client: slack.New("token", slack.OptionAPIURL(server.URL+"/"))}
+
+	rich := &gobot.RichMessage{Sections: []gobot.Section{{
+		Title:   "title",
+		Text:    "text",
+		Fields:  []gobot.Field{{Title: "a", Value: "b"}},
+		Context: "context",
+	}}}
+	tests := []struct {
+		name string
+		msg  gobot.OutgoingMessage
+		want map[string]string
+	}{
+		{
+			name: "text",
+			msg:  gobot.OutgoingMessage{ChannelID: "C123", Text: "hello"},
+			want: map[string]string{"channel": "C123", "text": "hello", "thread_ts": ""},
+		},
+		{
+			name: "thread",
+			msg:  gobot.OutgoingMessage{ChannelID: "C123", ThreadTS: "1.0", Text: "hello"},
+			want: map[string]string{"channel": "C123", "text": "hello", "thread_ts": "1.0"},
+		},
+		{
+			name: "blocks",
+			msg:  gobot.OutgoingMessage{ChannelID: "C123", Text: "fallback", Rich: rich},
+			want: map[string]string{
+				"text": "fallback",
+				"blocks": `[{"type":"section","text":{"type":"mrkdwn","text":"*title*\ntext"},` +
+					`"fields":[{"type":"mrkdwn","text":"*a*\nb"}]},` +
+					`{"type":"context","elements":[{"type":"mrkdwn","text":"context"}]}]`,
+			},
+		},
+		{
+			name: "attachments",
+			msg:  gobot.OutgoingMessage{ChannelID: "C123", Text: "fallback", Rich: &gobot.RichMessage{Color: gobot.ColorGood, Sections: rich.Sections}},
+			want: map[string]string{
+				"attachments": `[{"color":"good","fallback":"*title*\ntext\na: b\n_context_","title":"title","text":"text",` +
+					`"fields":[{"title":"a","value":"b","short":true}],"mrkdwn_in":["text","fields"],"footer":"context"}]`,
+			},
+		},
+		{
+			name: "buttons",
+			msg: gobot.OutgoingMessage{ChannelID: "C123", Text: "fallback", Rich: &gobot.RichMessage{
+				Sections: []gobot.Section{{Text: "text"}},
+				Buttons:  []gobot.Button{{Text: "Kill", Value: "kill 1", Style: gobot.ButtonDanger}},
+			}},
+			want: map[string]string{
+				"blocks": `[{"type":"section","text":{"type":"mrkdwn","text":"text"}},` +
+					`{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Kill"},` +
+					`"action_id":"gobot_button_0","value":"kill 1","style":"danger"}]}]`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := api.SendMessage(tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := (gobot.MessageRef{ChannelID: "C123", TS: "1.1"}); ref != want {
+				t.Errorf("ref = %v, want %v", ref, want)
+			}
+			for k, v := range tt.want {
+				if form.Get(k) != v {
+					t.Errorf("%s = %v, want %v", k, form.Get(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertMessageEvent(t *testing.T) {
+	tests := []struct {
+		json string
+		want gobot.Event
+	}{
+		{
+			json: `{"type":"message","channel":"C123","user":"U123","text":"ps","ts":"1.1"}`,
+			want: gobot.Event{Type: "message", Data: &gobot.MessageEvent{ChannelID: "C123", UserID: "U123", Text: "ps", TS: "1.1"}},
+		},
+		{
+			json: `{"type":"message","subtype":"message_changed","channel":"C123","ts":"1.2",` +
+				`"message":{"user":"U123","text":"ps -a","ts":"1.1","thread_ts":"1.0"},"previous_message":{"user":"U123","text":"ps","ts":"1.1"}}`,
+			want: gobot.Event{Type: "message", Data: &gobot.MessageEvent{
+				ChannelID:    "C123",
+				UserID:       "U123",
+				Text:         "ps -a",
+				SubType:      "message_changed",
+				TS:           "1.1",
+				ThreadTS:     "1.0",
+				Edited:       true,
+				PreviousText: "ps",
+			}},
+		},
+		{
+			json: `{"type":"message","subtype":"message_deleted","channel":"C123","ts":"1.2","deleted_ts":"1.1"}`,
+			want: gobot.Event{Type: gobot.EventMessageDeleted, Data: &gobot.MessageDeletedEvent{ChannelID: "C123", TS: "1.1"}},
+		},
+	}
+	for _, tt := range tests {
+		var data slack.MessageEvent
+		if err := json.Unmarshal([]byte(tt.json), &data); err != nil {
+			t.Fatal(err)
+		}
+		if got := convertMessageEvent(&data); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("convertMessageEvent(%s) = %+v, want %+v", tt.json, got.Data, tt.want.Data)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/li-go/gobot/gobot"
+)
+
+// workspace is a slack workspace a bot is run for, the commands are shared by all of them.
+type workspace struct {
+	// Name namespaces the tasks of the workspace, it's empty when there is only one workspace
+	Name          string `yaml:"name"`
+	Token         string `yaml:"token"`
+	AppToken      string `yaml:"app_token"`
+	SigningSecret string `yaml:"signing_secret"`
+	// Addr is the listen address of the events api, slash commands and button clicks receiver
+	Addr         string `yaml:"addr"`
+	AdminChannel string `yaml:"admin_channel"`
+	// Language is the language of replies unless users select theirs, it defaults to the one given by flag
+	Language string `yaml:"language"`
+	// Permissions lists the IDs of the users allowed to use a handler by its name, the handlers missing are open to everyone
+	Permissions map[string][]string `yaml:"permissions"`
+}
+
+// defaultWorkspace is the only workspace when no workspaces config is given, configured by env and flags.
+func defaultWorkspace() workspace {
+	return workspace{
+		Token:         os.Getenv("SLACK_TOKEN"),
+		AppToken:      os.Getenv("SLACK_APP_TOKEN"),
+		SigningSecret: os.Getenv("SLACK_SIGNING_SECRET"),
+		Addr:          eventsAddr,
+		AdminChannel:  adminChannel,
+		Language:      language,
+		Permissions:   permissions,
+	}
+}
+
+// loadWorkspaces loads the workspaces config in yaml format, ${VAR} in it is replaced by the environment variable,
+// e.g. `token: ${TEAM_A_TOKEN}` keeps tokens out of the file.
+func loadWorkspaces(filename string) ([]workspace, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var workspaces []workspace
+	if err := yaml.Unmarshal([]byte(os.ExpandEnv(string(buf))), &workspaces); err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, errors.New("no workspace configured")
+	}
+
+	names := make(map[string]bool)
+	addrs := make(map[string]string)
+	for i, w := range workspaces {
+		if len(w.Name) == 0 {
+			return nil, fmt.Errorf("workspace #%d: name is missing", i+1)
+		}
+		if names[w.Name] {
+			return nil, fmt.Errorf("workspace %s: duplicate name", w.Name)
+		}
+		names[w.Name] = true
+		if len(w.Token) == 0 {
+			return nil, fmt.Errorf("workspace %s: token is missing", w.Name)
+		}
+		if len(w.Addr) == 0 {
+			w.Addr = eventsAddr
+		}
+		if len(w.Language) == 0 {
+			w.Language = language
+		}
+		if !gobot.IsLanguage(w.Language) {
+			return nil, fmt.Errorf("workspace %s: unknown language %s", w.Name, w.Language)
+		}
+		workspaces[i] = w
+		if !w.listens() {
+			continue
+		}
+		if other, ok := addrs[w.Addr]; ok {
+			return nil, fmt.Errorf("workspace %s: addr %s is used by %s", w.Name, w.Addr, other)
+		}
+		addrs[w.Addr] = w.Name
+	}
+	return workspaces, nil
+}
+
+// listens tells whether the transport of the workspace serves HTTP on Addr.
+func (w workspace) listens() bool {
+	switch transportName {
+	case "events":
+		return true
+	case "rtm":
+		return len(w.SigningSecret) > 0
+	default:
+		return false
+	}
+}